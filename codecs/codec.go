@@ -0,0 +1,61 @@
+package codecs
+
+import "errors"
+
+// Codec encodes messages exchanged with a client.
+// The client picks a codec through the Sec-WebSocket-Protocol header
+// during the upgrade and the player keeps it for the whole connection.
+type Codec interface {
+	// Name is the WebSocket subprotocol of the codec
+	Name() string
+	// FrameType is websocket.TextMessage or websocket.BinaryMessage
+	FrameType() int
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+	// EncodeEnvelope and DecodeEnvelope handle the typed message wrapper,
+	// Payload is kept encoded with the same codec.
+	EncodeEnvelope(envelope Envelope) ([]byte, error)
+	DecodeEnvelope(data []byte) (Envelope, error)
+}
+
+// Envelope wraps typed messages in both directions
+type Envelope struct {
+	Type      string
+	RequestId string
+	Payload   []byte
+}
+
+var UnsupportedType = errors.New("type is not supported by the codec")
+
+// Encode marshals the payload and wraps it in an envelope
+func Encode(codec Codec, messageType, requestId string, payload any) ([]byte, error) {
+	raw, err := codec.Marshal(payload)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return codec.EncodeEnvelope(Envelope{Type: messageType, RequestId: requestId, Payload: raw})
+}
+
+// Find returns the codec of the subprotocol, or nil if there is none
+func Find(codecs []Codec, subprotocol string) Codec {
+	for _, codec := range codecs {
+		if codec.Name() == subprotocol {
+			return codec
+		}
+	}
+
+	return nil
+}
+
+// Names returns the subprotocols of the codecs in order of preference
+func Names(codecs []Codec) []string {
+	names := make([]string, 0, len(codecs))
+
+	for _, codec := range codecs {
+		names = append(names, codec.Name())
+	}
+
+	return names
+}
@@ -0,0 +1,52 @@
+package codecs
+
+import (
+	"encoding/json"
+
+	"github.com/gorilla/websocket"
+)
+
+// JSON is sent in text frames, so browsers can read messages without any library
+type JSON struct{}
+
+type jsonEnvelope struct {
+	Type      string          `json:"type"`
+	RequestId string          `json:"requestId,omitempty"`
+	Payload   json.RawMessage `json:"payload,omitempty"`
+}
+
+func (JSON) Name() string {
+	return "json"
+}
+
+func (JSON) FrameType() int {
+	return websocket.TextMessage
+}
+
+func (JSON) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (JSON) Unmarshal(data []byte, v any) error {
+	return json.Unmarshal(data, v)
+}
+
+func (JSON) EncodeEnvelope(envelope Envelope) ([]byte, error) {
+	return json.Marshal(jsonEnvelope{
+		Type:      envelope.Type,
+		RequestId: envelope.RequestId,
+		Payload:   envelope.Payload,
+	})
+}
+
+func (JSON) DecodeEnvelope(data []byte) (Envelope, error) {
+	var envelope jsonEnvelope
+
+	err := json.Unmarshal(data, &envelope)
+
+	return Envelope{
+		Type:      envelope.Type,
+		RequestId: envelope.RequestId,
+		Payload:   envelope.Payload,
+	}, err
+}
@@ -0,0 +1,64 @@
+package codecs
+
+import (
+	"bytes"
+
+	"github.com/gorilla/websocket"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// MessagePack is a compact binary alternative to JSON for native clients.
+// Structs are encoded with their json tags, so the same types serve both codecs.
+type MessagePack struct{}
+
+type msgpackEnvelope struct {
+	Type      string             `msgpack:"type"`
+	RequestId string             `msgpack:"requestId,omitempty"`
+	Payload   msgpack.RawMessage `msgpack:"payload,omitempty"`
+}
+
+func (MessagePack) Name() string {
+	return "msgpack"
+}
+
+func (MessagePack) FrameType() int {
+	return websocket.BinaryMessage
+}
+
+func (MessagePack) Marshal(v any) ([]byte, error) {
+	var buffer bytes.Buffer
+
+	encoder := msgpack.NewEncoder(&buffer)
+	encoder.SetCustomStructTag("json")
+
+	err := encoder.Encode(v)
+
+	return buffer.Bytes(), err
+}
+
+func (MessagePack) Unmarshal(data []byte, v any) error {
+	decoder := msgpack.NewDecoder(bytes.NewReader(data))
+	decoder.SetCustomStructTag("json")
+
+	return decoder.Decode(v)
+}
+
+func (MessagePack) EncodeEnvelope(envelope Envelope) ([]byte, error) {
+	return msgpack.Marshal(msgpackEnvelope{
+		Type:      envelope.Type,
+		RequestId: envelope.RequestId,
+		Payload:   envelope.Payload,
+	})
+}
+
+func (MessagePack) DecodeEnvelope(data []byte) (Envelope, error) {
+	var envelope msgpackEnvelope
+
+	err := msgpack.Unmarshal(data, &envelope)
+
+	return Envelope{
+		Type:      envelope.Type,
+		RequestId: envelope.RequestId,
+		Payload:   envelope.Payload,
+	}, err
+}
@@ -0,0 +1,203 @@
+package codecs
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+
+	"github.com/gorilla/websocket"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+)
+
+// Protobuf encodes generated proto.Message types.
+// Plain structs of scalar fields, like the framework's own messages, are encoded
+// as protobuf messages whose field numbers follow the struct declaration order,
+// so clients can describe them with a regular .proto file.
+//
+// The envelope is the message {string type = 1; string request_id = 2; bytes payload = 3;}
+type Protobuf struct{}
+
+func (Protobuf) Name() string {
+	return "protobuf"
+}
+
+func (Protobuf) FrameType() int {
+	return websocket.BinaryMessage
+}
+
+func (Protobuf) Marshal(v any) ([]byte, error) {
+	if message, ok := v.(proto.Message); ok {
+		return proto.Marshal(message)
+	}
+
+	value := reflect.Indirect(reflect.ValueOf(v))
+
+	if value.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("%w: %T", UnsupportedType, v)
+	}
+
+	var output []byte
+
+	for i := 0; i < value.NumField(); i++ {
+		if !value.Type().Field(i).IsExported() {
+			continue
+		}
+
+		number := protowire.Number(i + 1)
+		field := value.Field(i)
+
+		switch field.Kind() {
+		case reflect.String:
+			output = protowire.AppendTag(output, number, protowire.BytesType)
+			output = protowire.AppendString(output, field.String())
+		case reflect.Slice:
+			if field.Type().Elem().Kind() != reflect.Uint8 {
+				return nil, fmt.Errorf("%w: %T.%s", UnsupportedType, v, value.Type().Field(i).Name)
+			}
+			output = protowire.AppendTag(output, number, protowire.BytesType)
+			output = protowire.AppendBytes(output, field.Bytes())
+		case reflect.Bool:
+			output = protowire.AppendTag(output, number, protowire.VarintType)
+			output = protowire.AppendVarint(output, protowire.EncodeBool(field.Bool()))
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			output = protowire.AppendTag(output, number, protowire.VarintType)
+			output = protowire.AppendVarint(output, uint64(field.Int()))
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			output = protowire.AppendTag(output, number, protowire.VarintType)
+			output = protowire.AppendVarint(output, field.Uint())
+		case reflect.Float32:
+			output = protowire.AppendTag(output, number, protowire.Fixed32Type)
+			output = protowire.AppendFixed32(output, math.Float32bits(float32(field.Float())))
+		case reflect.Float64:
+			output = protowire.AppendTag(output, number, protowire.Fixed64Type)
+			output = protowire.AppendFixed64(output, math.Float64bits(field.Float()))
+		default:
+			return nil, fmt.Errorf("%w: %T.%s", UnsupportedType, v, value.Type().Field(i).Name)
+		}
+	}
+
+	return output, nil
+}
+
+func (Protobuf) Unmarshal(data []byte, v any) error {
+	if message, ok := v.(proto.Message); ok {
+		return proto.Unmarshal(data, message)
+	}
+
+	pointer := reflect.ValueOf(v)
+
+	if pointer.Kind() != reflect.Pointer || pointer.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("%w: %T", UnsupportedType, v)
+	}
+
+	value := pointer.Elem()
+
+	for len(data) > 0 {
+		number, wireType, n := protowire.ConsumeTag(data)
+
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+
+		data = data[n:]
+
+		index := int(number) - 1
+
+		if index < 0 || index >= value.NumField() || !value.Type().Field(index).IsExported() {
+			n = protowire.ConsumeFieldValue(number, wireType, data)
+
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+
+			data = data[n:]
+			continue
+		}
+
+		field := value.Field(index)
+
+		switch wireType {
+		case protowire.BytesType:
+			raw, n := protowire.ConsumeBytes(data)
+
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+
+			data = data[n:]
+
+			switch {
+			case field.Kind() == reflect.String:
+				field.SetString(string(raw))
+			case field.Kind() == reflect.Slice && field.Type().Elem().Kind() == reflect.Uint8:
+				field.SetBytes(append([]byte(nil), raw...))
+			default:
+				return fmt.Errorf("%w: %T.%s", UnsupportedType, v, value.Type().Field(index).Name)
+			}
+		case protowire.VarintType:
+			raw, n := protowire.ConsumeVarint(data)
+
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+
+			data = data[n:]
+
+			switch field.Kind() {
+			case reflect.Bool:
+				field.SetBool(protowire.DecodeBool(raw))
+			case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+				field.SetInt(int64(raw))
+			case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+				field.SetUint(raw)
+			default:
+				return fmt.Errorf("%w: %T.%s", UnsupportedType, v, value.Type().Field(index).Name)
+			}
+		case protowire.Fixed64Type:
+			raw, n := protowire.ConsumeFixed64(data)
+
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+
+			data = data[n:]
+
+			if field.Kind() != reflect.Float64 {
+				return fmt.Errorf("%w: %T.%s", UnsupportedType, v, value.Type().Field(index).Name)
+			}
+
+			field.SetFloat(math.Float64frombits(raw))
+		case protowire.Fixed32Type:
+			raw, n := protowire.ConsumeFixed32(data)
+
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+
+			data = data[n:]
+
+			if field.Kind() != reflect.Float32 {
+				return fmt.Errorf("%w: %T.%s", UnsupportedType, v, value.Type().Field(index).Name)
+			}
+
+			field.SetFloat(float64(math.Float32frombits(raw)))
+		default:
+			return fmt.Errorf("%w: %T.%s", UnsupportedType, v, value.Type().Field(index).Name)
+		}
+	}
+
+	return nil
+}
+
+func (codec Protobuf) EncodeEnvelope(envelope Envelope) ([]byte, error) {
+	return codec.Marshal(envelope)
+}
+
+func (codec Protobuf) DecodeEnvelope(data []byte) (Envelope, error) {
+	var envelope Envelope
+
+	err := codec.Unmarshal(data, &envelope)
+
+	return envelope, err
+}
@@ -0,0 +1,220 @@
+package codecs
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+type scalars struct {
+	String  string
+	Bytes   []byte
+	Bool    bool
+	Int     int
+	Int8    int8
+	Int16   int16
+	Int32   int32
+	Int64   int64
+	Uint    uint
+	Uint8   uint8
+	Uint16  uint16
+	Uint32  uint32
+	Uint64  uint64
+	Float32 float32
+	Float64 float64
+	hidden  string
+}
+
+func TestProtobufEnvelopeRoundTrip(t *testing.T) {
+	codec := Protobuf{}
+
+	tests := []struct {
+		name     string
+		envelope Envelope
+	}{
+		{"empty", Envelope{Payload: []byte{}}},
+		{"full", Envelope{Type: "move", RequestId: "42", Payload: []byte{0, 1, 2, 255}}},
+		{"unicode", Envelope{Type: "chat", RequestId: "é", Payload: []byte("سلام")}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			data, err := codec.EncodeEnvelope(test.envelope)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			envelope, err := codec.DecodeEnvelope(data)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if envelope.Type != test.envelope.Type || envelope.RequestId != test.envelope.RequestId ||
+				!bytes.Equal(envelope.Payload, test.envelope.Payload) {
+				t.Fatalf("got %+v, want %+v", envelope, test.envelope)
+			}
+		})
+	}
+}
+
+func TestProtobufScalarsRoundTrip(t *testing.T) {
+	codec := Protobuf{}
+
+	tests := []struct {
+		name  string
+		value scalars
+	}{
+		{"zero", scalars{}},
+		{"positive", scalars{
+			String: "text", Bytes: []byte("raw"), Bool: true,
+			Int: 1, Int8: 2, Int16: 3, Int32: 4, Int64: 5,
+			Uint: 6, Uint8: 7, Uint16: 8, Uint32: 9, Uint64: 10,
+			Float32: 1.5, Float64: 2.25,
+		}},
+		{"negative", scalars{
+			Int: -1, Int8: math.MinInt8, Int16: math.MinInt16, Int32: math.MinInt32, Int64: math.MinInt64,
+			Float32: -1.5, Float64: math.Inf(-1),
+		}},
+		{"maximum", scalars{
+			Int: math.MaxInt, Int8: math.MaxInt8, Int16: math.MaxInt16, Int32: math.MaxInt32, Int64: math.MaxInt64,
+			Uint: math.MaxUint, Uint8: math.MaxUint8, Uint16: math.MaxUint16, Uint32: math.MaxUint32, Uint64: math.MaxUint64,
+			Float32: math.MaxFloat32, Float64: math.MaxFloat64,
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			data, err := codec.Marshal(&test.value)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var value scalars
+
+			if err = codec.Unmarshal(data, &value); err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(value, test.value) {
+				t.Fatalf("got %+v, want %+v", value, test.value)
+			}
+		})
+	}
+}
+
+func TestProtobufSkipsUnexportedFields(t *testing.T) {
+	codec := Protobuf{}
+
+	data, err := codec.Marshal(scalars{hidden: "secret"})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if bytes.Contains(data, []byte("secret")) {
+		t.Fatal("unexported field is encoded")
+	}
+}
+
+func TestProtobufRejectsUnsupportedTypes(t *testing.T) {
+	codec := Protobuf{}
+
+	tests := []struct {
+		name  string
+		value any
+	}{
+		{"not a struct", 42},
+		{"slice of strings", struct{ Names []string }{[]string{"a"}}},
+		{"map", struct{ Scores map[string]int }{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := codec.Marshal(test.value)
+
+			if !errors.Is(err, UnsupportedType) {
+				t.Fatalf("got %v, want %v", err, UnsupportedType)
+			}
+		})
+	}
+}
+
+// envelopeDescriptor describes the envelope as documented on Protobuf
+func envelopeDescriptor(t *testing.T) protoreflect.MessageDescriptor {
+	field := func(name string, number int32, kind descriptorpb.FieldDescriptorProto_Type) *descriptorpb.FieldDescriptorProto {
+		return &descriptorpb.FieldDescriptorProto{
+			Name:     proto.String(name),
+			JsonName: proto.String(name),
+			Number:   proto.Int32(number),
+			Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+			Type:     kind.Enum(),
+		}
+	}
+
+	file, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:    proto.String("envelope.proto"),
+		Package: proto.String("relay"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{{
+			Name: proto.String("Envelope"),
+			Field: []*descriptorpb.FieldDescriptorProto{
+				field("type", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING),
+				field("request_id", 2, descriptorpb.FieldDescriptorProto_TYPE_STRING),
+				field("payload", 3, descriptorpb.FieldDescriptorProto_TYPE_BYTES),
+			},
+		}},
+	}, nil)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return file.Messages().Get(0)
+}
+
+func TestProtobufEnvelopeMatchesProtoMarshal(t *testing.T) {
+	codec := Protobuf{}
+	descriptor := envelopeDescriptor(t)
+
+	message := dynamicpb.NewMessage(descriptor)
+	message.Set(descriptor.Fields().ByNumber(1), protoreflect.ValueOfString("move"))
+	message.Set(descriptor.Fields().ByNumber(2), protoreflect.ValueOfString("42"))
+	message.Set(descriptor.Fields().ByNumber(3), protoreflect.ValueOfBytes([]byte{1, 2, 3}))
+
+	want, err := proto.MarshalOptions{Deterministic: true}.Marshal(message)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := codec.EncodeEnvelope(Envelope{Type: "move", RequestId: "42", Payload: []byte{1, 2, 3}})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(got, want) {
+		t.Fatalf("got %x, want %x", got, want)
+	}
+
+	envelope, err := codec.DecodeEnvelope(want)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if envelope.Type != "move" || envelope.RequestId != "42" || !bytes.Equal(envelope.Payload, []byte{1, 2, 3}) {
+		t.Fatalf("got %+v from proto.Marshal", envelope)
+	}
+}
@@ -0,0 +1,261 @@
+package entities
+
+import (
+	"hash/fnv"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/AmirRezaM75/kenopsiarelay/codecs"
+	"github.com/AmirRezaM75/kenopsiarelay/pkg/logx"
+	"go.uber.org/zap"
+)
+
+// BotContext is given to a bot on each message. Bots see the game like a human
+// client does, through dispatched messages, so they must not read Game.State.
+type BotContext[S GameState] struct {
+	Hub    *Hub[S]
+	Game   *Game[S]
+	Player *Player
+	// Codec is the codec of the bot's messages, the hub's default codec
+	Codec codecs.Codec
+}
+
+// Bot decides the moves of a bot player. React receives every message dispatched
+// to the bot and returns the messages to send, which go through OnMessageReceived
+// exactly like the messages of a human player, after the think delay.
+type Bot[S GameState] interface {
+	React(ctx *BotContext[S], message []byte) ([][]byte, error)
+}
+
+// BotFactory creates the bot driving the given IsBot player, nil leaves the player idle
+type BotFactory[S GameState] func(game *Game[S], player *Player) Bot[S]
+
+// startBots starts a goroutine for each bot player of the game. The goroutine stops
+// when the game is removed, the hub shuts down or the bot player is kicked.
+func (hub *Hub[S]) startBots(game *Game[S]) {
+	if hub.BotFactory == nil {
+		return
+	}
+
+	game.Players.Range(func(playerId string, player *Player) bool {
+		if player.IsBot {
+			hub.startBot(game, player)
+		}
+		return true
+	})
+}
+
+func (hub *Hub[S]) startBot(game *Game[S], player *Player) {
+	bot := hub.BotFactory(game, player)
+
+	if bot == nil {
+		return
+	}
+
+	// The bot's connection has no socket, its queue is consumed by runBot
+	connection := NewConnection(nil, ConnectionOptions{
+		QueueSize:       hub.PlayerQueueSize,
+		Codec:           hub.Codecs[0],
+		DuplicatePolicy: DuplicateConnectionReplace,
+	})
+
+	_, err := player.Reconnect(connection)
+
+	if err != nil {
+		logx.Logger.Error(
+			err.Error(),
+			zap.String("desc", "could not attach bot connection"),
+			zap.String("gameId", game.Id),
+			zap.String("playerId", player.Id),
+		)
+		return
+	}
+
+	ctx := &BotContext[S]{Hub: hub, Game: game, Player: player, Codec: hub.Codecs[0]}
+
+	go hub.runBot(ctx, bot, connection, false)
+}
+
+// runBot feeds the bot with the messages of its connection. A takeover bot
+// plays for a disconnected human and stops when the human is back.
+func (hub *Hub[S]) runBot(ctx *BotContext[S], bot Bot[S], connection *Connection, takeover bool) {
+	game, player := ctx.Game, ctx.Player
+
+	// Hooks of humans are not fired for bots, detach only releases the connection
+	defer func() {
+		last, generation := player.detach(connection, true)
+
+		if !takeover {
+			return
+		}
+
+		handedBack := player.releaseBot(connection)
+
+		if game.ctx.Err() != nil {
+			return
+		}
+
+		if handedBack {
+			hub.handback(game, player)
+			return
+		}
+
+		// The bot stopped while the player is still away, e.g. it was kicked,
+		// so nobody plays the seat anymore and the player leaves
+		if last {
+			leave(player, hub, game, generation)
+		}
+	}()
+
+	moves := &botMoves{ready: make(chan struct{}, 1)}
+	stop := make(chan struct{})
+
+	defer close(stop)
+
+	go hub.play(ctx, connection, moves, stop)
+
+	for {
+		select {
+		case <-game.ctx.Done():
+			return
+		case <-connection.ready:
+		}
+
+		for {
+			message, ok, closed := player.dequeue(connection)
+
+			if closed {
+				return
+			}
+
+			if !ok {
+				break
+			}
+
+			var replies [][]byte
+
+			err := hub.protect(game, player, "bot", func() (err error) {
+				replies, err = bot.React(ctx, message.Body)
+				return err
+			})
+
+			if err != nil {
+				logx.Logger.Error(
+					err.Error(),
+					zap.String("desc", "bot could not react to message"),
+					zap.String("gameId", game.Id),
+					zap.String("playerId", player.Id),
+				)
+				continue
+			}
+
+			moves.push(replies)
+		}
+	}
+}
+
+// botMoves are the replies of a bot waiting for the think delay. They are played
+// by their own goroutine, so the bot's queue keeps being read while it thinks.
+type botMoves struct {
+	mutex   sync.Mutex
+	pending [][]byte
+	ready   chan struct{}
+}
+
+func (moves *botMoves) push(replies [][]byte) {
+	if len(replies) == 0 {
+		return
+	}
+
+	moves.mutex.Lock()
+	moves.pending = append(moves.pending, replies...)
+	moves.mutex.Unlock()
+
+	select {
+	case moves.ready <- struct{}{}:
+	default:
+	}
+}
+
+func (moves *botMoves) pop() ([]byte, bool) {
+	moves.mutex.Lock()
+	defer moves.mutex.Unlock()
+
+	if len(moves.pending) == 0 {
+		return nil, false
+	}
+
+	move := moves.pending[0]
+	moves.pending[0] = nil
+	moves.pending = moves.pending[1:]
+
+	return move, true
+}
+
+// play sends the moves of the bot through OnMessageReceived in order, each one after
+// the think delay, until runBot stops. Moves of a stopped bot are discarded.
+func (hub *Hub[S]) play(ctx *BotContext[S], connection *Connection, moves *botMoves, stop <-chan struct{}) {
+	game, player := ctx.Game, ctx.Player
+	jitter := thinkJitter(game.Seed, player.Id)
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-game.ctx.Done():
+			return
+		case <-moves.ready:
+		}
+
+		for {
+			move, ok := moves.pop()
+
+			if !ok {
+				break
+			}
+
+			select {
+			case <-stop:
+				return
+			case <-game.ctx.Done():
+				return
+			case <-time.After(hub.thinkDelay(jitter)):
+			}
+
+			// The player may have taken the seat back while the bot was thinking
+			if player.connectionClosed(connection) {
+				return
+			}
+
+			err := hub.HandleMessage(game, player, move)
+
+			if err != nil {
+				hub.replyHandlerError(game, player, err)
+			}
+		}
+	}
+}
+
+// thinkDelay is BotThinkDelay plus a random jitter up to BotThinkJitter,
+// so bots do not answer instantly and in lockstep
+func (hub *Hub[S]) thinkDelay(jitter *rand.Rand) time.Duration {
+	delay := hub.BotThinkDelay
+
+	if hub.BotThinkJitter > 0 {
+		delay += time.Duration(jitter.Int63n(int64(hub.BotThinkJitter)))
+	}
+
+	return delay
+}
+
+// thinkJitter is the source of the think delays of one bot. It is derived from the
+// game's Seed so delays are reproducible, but it is separate from Game.Rand: the
+// delays depend on timing, drawing them from the game's source would change the
+// sequence seen by the rules and break replays.
+func thinkJitter(seed int64, playerId string) *rand.Rand {
+	hash := fnv.New64a()
+	hash.Write([]byte(playerId))
+
+	return rand.New(rand.NewSource(seed ^ int64(hash.Sum64())))
+}
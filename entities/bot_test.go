@@ -0,0 +1,142 @@
+package entities
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/AmirRezaM75/kenopsiarelay/pkg/logx"
+	"github.com/AmirRezaM75/kenopsiarelay/pkg/randx"
+	"github.com/AmirRezaM75/kenopsiarelay/schemas"
+)
+
+type echoBot struct{}
+
+func (echoBot) React(ctx *BotContext[int], message []byte) ([][]byte, error) {
+	return [][]byte{message}, nil
+}
+
+func TestBotSurvivesBroadcastsWhileThinking(t *testing.T) {
+	logx.NewLogger()
+
+	var moves atomic.Int64
+
+	hub := NewHub(&HubConfig[int]{
+		Context:         context.Background(),
+		PlayerQueueSize: 2,
+		BotThinkDelay:   20 * time.Millisecond,
+		BotFactory: func(game *Game[int], player *Player) Bot[int] {
+			return echoBot{}
+		},
+		OnMessageReceived: func(hub *Hub[int], game *Game[int], player *Player, message []byte) error {
+			moves.Add(1)
+			return nil
+		},
+	})
+
+	game := &Game[int]{Id: "game"}
+	bot := &Player{Id: "bot", GameId: game.Id, IsBot: true}
+	game.Players.Store(bot.Id, bot)
+
+	hub.AddGame(game)
+	defer hub.RemoveGame(game.Id)
+
+	broadcast := func() {
+		hub.dispatchSafely(&schemas.DispatcherMessage{
+			Body:        []byte("state"),
+			GameId:      game.Id,
+			ReceiverIds: []string{bot.Id},
+		})
+	}
+
+	for i := 0; i < 10; i++ {
+		broadcast()
+	}
+
+	if count := bot.ConnectionCount(); count != 1 {
+		t.Fatalf("bot has %d connections, want 1", count)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+
+	for moves.Load() == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	played := moves.Load()
+
+	if played == 0 {
+		t.Fatal("bot did not play after the broadcasts")
+	}
+
+	// The bot still reacts to messages once it has caught up
+	time.Sleep(300 * time.Millisecond)
+
+	played = moves.Load()
+
+	broadcast()
+
+	deadline = time.Now().Add(2 * time.Second)
+
+	for moves.Load() == played && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if moves.Load() == played {
+		t.Fatal("bot stopped playing")
+	}
+}
+
+func TestBotThinkJitterKeepsGameRand(t *testing.T) {
+	logx.NewLogger()
+
+	var moves atomic.Int64
+
+	hub := NewHub(&HubConfig[int]{
+		Context:        context.Background(),
+		BotThinkJitter: 5 * time.Millisecond,
+		BotFactory: func(game *Game[int], player *Player) Bot[int] {
+			return echoBot{}
+		},
+		OnMessageReceived: func(hub *Hub[int], game *Game[int], player *Player, message []byte) error {
+			moves.Add(1)
+			return nil
+		},
+	})
+
+	game := &Game[int]{Id: "game"}
+	game.UseSeed(7)
+	bot := &Player{Id: "bot", GameId: game.Id, IsBot: true}
+	game.Players.Store(bot.Id, bot)
+
+	hub.AddGame(game)
+	defer hub.RemoveGame(game.Id)
+
+	for i := 0; i < 5; i++ {
+		hub.dispatchSafely(&schemas.DispatcherMessage{
+			Body:        []byte("state"),
+			GameId:      game.Id,
+			ReceiverIds: []string{bot.Id},
+		})
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+
+	for moves.Load() < 5 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if moves.Load() < 5 {
+		t.Fatalf("bot played %d moves, want 5", moves.Load())
+	}
+
+	// The think delays must not have consumed the game's sequence
+	replay := randx.New(7)
+
+	for i := 0; i < 3; i++ {
+		if got, want := game.Rand().Int63(), replay.Int63(); got != want {
+			t.Fatalf("draw %d of the game is %d, want %d", i, got, want)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/AmirRezaM75/kenopsiarelay/codecs"
+	"github.com/gorilla/websocket"
+)
+
+// ConnectionOptions are applied to a player's connection, see NewConnection
+type ConnectionOptions struct {
+	// QueueSize is the capacity of the outbound queue, non-positive values fall back to the default
+	QueueSize int
+	// Codec is negotiated through the WebSocket subprotocol
+	Codec codecs.Codec
+	// PingInterval is how often the server pings the client, the connection is
+	// considered dead when no pong arrives within PingInterval + PongTimeout
+	PingInterval time.Duration
+	PongTimeout  time.Duration
+	// WriteTimeout is the deadline of each write to the connection
+	WriteTimeout time.Duration
+	// MaxMessageSize is the read limit of inbound messages in bytes
+	MaxMessageSize int64
+	// RateLimit is applied to inbound messages
+	RateLimit InboundRateLimit
+	// DuplicatePolicy is applied when the player already has an open connection
+	DuplicatePolicy DuplicateConnectionPolicy
+}
+
+// ConnectionOptions returns the options of a new connection.
+// The codec is the one selected by the subprotocol, or the default codec.
+func (hub *Hub[S]) ConnectionOptions(connection *websocket.Conn) ConnectionOptions {
+	codec := codecs.Find(hub.Codecs, connection.Subprotocol())
+
+	if codec == nil {
+		codec = hub.Codecs[0]
+	}
+
+	return ConnectionOptions{
+		QueueSize:    hub.PlayerQueueSize,
+		Codec:        codec,
+		PingInterval: hub.PingInterval,
+		PongTimeout:  hub.PongTimeout,
+		WriteTimeout: hub.WriteTimeout,
+
+		MaxMessageSize: hub.MaxMessageSize,
+		RateLimit:      hub.InboundRateLimit,
+
+		DuplicatePolicy: hub.DuplicateConnectionPolicy,
+	}
+}
+
+// CodecOf returns the codec of the player, players who never connected get the default codec
+func (hub *Hub[S]) CodecOf(player *Player) codecs.Codec {
+	player.mutex.Lock()
+	defer player.mutex.Unlock()
+
+	if player.codec == nil {
+		return hub.Codecs[0]
+	}
+
+	return player.codec
+}
@@ -0,0 +1,232 @@
+package entities
+
+import (
+	"errors"
+	"time"
+	"unicode/utf8"
+
+	"github.com/AmirRezaM75/kenopsiarelay/pkg/logx"
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+)
+
+// DuplicateConnectionPolicy decides what happens when a player who is already
+// connected joins again, e.g. by opening the game in a second browser tab.
+type DuplicateConnectionPolicy int
+
+const (
+	// DuplicateConnectionReplace closes the previous connections with DuplicateConnectionCloseCode,
+	// so the old tab can tell the user the session continues somewhere else
+	DuplicateConnectionReplace DuplicateConnectionPolicy = iota
+	// DuplicateConnectionReject refuses the new connection with DuplicateConnection
+	DuplicateConnectionReject
+	// DuplicateConnectionAllowMultiple keeps every connection, outbound messages are
+	// fanned out to all of them and inbound messages of any of them reach the game.
+	// All connections of a player must use the same codec, see CodecMismatch.
+	DuplicateConnectionAllowMultiple
+)
+
+// DuplicateConnectionCloseCode is an application close code (4000-4999), clients
+// should not reconnect automatically when they receive it, otherwise two tabs
+// would keep taking the session over from each other.
+const DuplicateConnectionCloseCode = 4001
+
+const DuplicateConnectionCloseReason = "session taken over"
+
+// maxCloseReasonSize is what is left of a 125 bytes control frame after the close code
+const maxCloseReasonSize = 123
+
+var (
+	DuplicateConnection = errors.New("player is already connected")
+	CodecMismatch       = errors.New("codec differs from the other connections of the player")
+)
+
+// Connection is a single WebSocket connection of a player with its own outbound queue.
+// Player.mutex guards the mutable fields of all connections of the player.
+type Connection struct {
+	Socket  *websocket.Conn
+	options ConnectionOptions
+	// queue is the outbound queue, ready is signalled when a message
+	// is queued or the connection is closed, see Player.dequeue
+	queue []Envelope
+	ready chan struct{}
+	// To keep track of closed connection
+	closed bool
+	// closeCode and closeReason are written by Write in a close frame
+	// after the outbound queue is flushed, see Player.Close.
+	closeCode   int
+	closeReason string
+	// handedBack is set on a takeover bot's connection when the player is back, see Player.Reconnect
+	handedBack bool
+}
+
+// NewConnection wraps the socket, it is attached to a player by Player.Reconnect
+func NewConnection(socket *websocket.Conn, options ConnectionOptions) *Connection {
+	if options.QueueSize <= 0 {
+		options.QueueSize = defaultPlayerQueueSize
+	}
+
+	return &Connection{
+		Socket:  socket,
+		options: options,
+		queue:   make([]Envelope, 0, options.QueueSize),
+		ready:   make(chan struct{}, 1),
+	}
+}
+
+// ConnectionCount returns the number of open connections of the player
+func (player *Player) ConnectionCount() int {
+	player.mutex.Lock()
+	defer player.mutex.Unlock()
+
+	count := 0
+
+	for _, connection := range player.connections {
+		if !connection.closed {
+			count++
+		}
+	}
+
+	return count
+}
+
+// closeConnection closes the outbound queue and the socket of the connection.
+// It must be called with the mutex held, the connection stays attached until
+// its Read loop exits, see detach.
+func (player *Player) closeConnection(connection *Connection) {
+	player.closeQueue(connection)
+
+	// Bots have no socket, see Hub.startBot
+	if connection.Socket != nil {
+		err := connection.Socket.Close()
+
+		if err != nil {
+			logx.Logger.Info(
+				err.Error(),
+				zap.String("desc", "could not close player connection"),
+				zap.String("playerId", player.Id),
+			)
+		}
+	}
+}
+
+// closeQueue stops the Write loop of the connection, it must be called with the mutex held
+func (player *Player) closeQueue(connection *Connection) {
+	if !connection.closed {
+		connection.closed = true
+		connection.wake()
+	}
+
+	player.updateConnected()
+}
+
+// connectionClosed reports whether the connection is closed or kicked
+func (player *Player) connectionClosed(connection *Connection) bool {
+	player.mutex.Lock()
+	defer player.mutex.Unlock()
+
+	return connection.closed
+}
+
+// updateConnected must be called with the mutex held
+func (player *Player) updateConnected() {
+	for _, connection := range player.connections {
+		if !connection.closed {
+			player.IsConnected = true
+			return
+		}
+	}
+
+	player.IsConnected = false
+}
+
+// detach closes the connection and removes it from the player. It reports whether
+// it was the last connection, in which case the player is marked as away and
+// the current generation is returned for the reconnection grace period.
+// A connection replaced by Reconnect is already detached, so nothing happens.
+// The socket is left open when closeSocket is false, see Hub.Detach.
+func (player *Player) detach(connection *Connection, closeSocket bool) (last bool, generation uint64) {
+	player.mutex.Lock()
+	defer player.mutex.Unlock()
+
+	if closeSocket {
+		player.closeConnection(connection)
+	} else {
+		player.closeQueue(connection)
+	}
+
+	index := -1
+
+	for i, attached := range player.connections {
+		if attached == connection {
+			index = i
+			break
+		}
+	}
+
+	if index == -1 {
+		return false, player.generation
+	}
+
+	player.connections = append(player.connections[:index], player.connections[index+1:]...)
+
+	if len(player.connections) > 0 {
+		return false, player.generation
+	}
+
+	player.away = true
+	player.disconnectedAt = time.Now()
+
+	return true, player.generation
+}
+
+// Detach releases a connection attached by Reconnect which is not going to be served,
+// e.g. because a join hook has failed. The socket is left open so the caller can tell
+// the client why, the player is unsubscribed when it was its last connection.
+func (hub *Hub[S]) Detach(player *Player, connection *Connection) {
+	if last, generation := player.detach(connection, false); last {
+		unsubscribe(player, hub, generation)
+	}
+}
+
+// kickConnection closes a single connection with a close frame, the other
+// connections of the player are left untouched
+func (player *Player) kickConnection(connection *Connection, code int, reason string) {
+	if connection.Socket != nil {
+		// WriteControl is safe to be called concurrently with Write goroutine
+		err := connection.Socket.WriteControl(
+			websocket.CloseMessage,
+			closeMessage(code, reason),
+			time.Now().Add(time.Second),
+		)
+
+		if err != nil {
+			logx.Logger.Info(
+				err.Error(),
+				zap.String("desc", "could not write close message"),
+				zap.String("playerId", player.Id),
+			)
+		}
+	}
+
+	player.mutex.Lock()
+	defer player.mutex.Unlock()
+
+	player.closeConnection(connection)
+}
+
+// closeMessage formats a close frame, a longer reason would make WriteControl fail,
+// so it is truncated without cutting a multi-byte character in half
+func closeMessage(code int, reason string) []byte {
+	if len(reason) > maxCloseReasonSize {
+		cut := maxCloseReasonSize
+
+		for cut > 0 && !utf8.RuneStart(reason[cut]) {
+			cut--
+		}
+
+		reason = reason[:cut]
+	}
+
+	return websocket.FormatCloseMessage(code, reason)
+}
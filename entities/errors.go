@@ -0,0 +1,116 @@
+package entities
+
+import (
+	"errors"
+
+	"github.com/AmirRezaM75/kenopsiarelay/codecs"
+	"github.com/AmirRezaM75/kenopsiarelay/pkg/logx"
+	"github.com/AmirRezaM75/kenopsiarelay/schemas"
+	"go.uber.org/zap"
+)
+
+// ClientError is returned by handlers to reject a message.
+// It is sent back only to the sending player, any other error is logged
+// and replaced with InternalClientError so internals never leak to clients.
+type ClientError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	// RequestId is the id of the offending request, if the protocol has one
+	RequestId string `json:"requestId,omitempty"`
+}
+
+func NewClientError(code, message string) *ClientError {
+	return &ClientError{Code: code, Message: message}
+}
+
+func (clientError *ClientError) Error() string {
+	return clientError.Code + ": " + clientError.Message
+}
+
+// WithRequestId returns a copy of the error referring to the given request
+func (clientError *ClientError) WithRequestId(requestId string) *ClientError {
+	output := *clientError
+	output.RequestId = requestId
+	return &output
+}
+
+var InternalClientError = NewClientError("internal_error", "Something goes wrong!")
+
+// ErrorEncoder encodes the error sent to a client with the client's codec
+type ErrorEncoder func(codec codecs.Codec, clientError *ClientError) ([]byte, error)
+
+// DefaultErrorEncoder wraps the error in an envelope of type "error", e.g. with JSON
+// {"type":"error","requestId":"...","payload":{"code":"...","message":"..."}}
+func DefaultErrorEncoder(codec codecs.Codec, clientError *ClientError) ([]byte, error) {
+	type ErrorPayload struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	}
+
+	payload := ErrorPayload{Code: clientError.Code, Message: clientError.Message}
+
+	return codecs.Encode(codec, "error", clientError.RequestId, payload)
+}
+
+// ReplyError sends the error only to the given player through Dispatch,
+// so it is ordered with the other messages of the game
+func (hub *Hub[S]) ReplyError(game *Game[S], player *Player, clientError *ClientError) {
+	codec := hub.CodecOf(player)
+
+	body, err := hub.ErrorEncoder(codec, clientError)
+
+	if err != nil {
+		logx.Logger.Error(
+			err.Error(),
+			zap.String("desc", "could not encode client error"),
+			zap.String("gameId", game.Id),
+			zap.String("playerId", player.Id),
+		)
+		return
+	}
+
+	message := &schemas.DispatcherMessage{
+		Body:        body,
+		GameId:      game.Id,
+		ReceiverIds: []string{player.Id},
+		FrameType:   codec.FrameType(),
+	}
+
+	// Spectators are not receivers of Dispatch, their only input is rejected anyway
+	if player.IsSpectator {
+		hub.deliver(player, message)
+		return
+	}
+
+	hub.Send(message)
+}
+
+// replyHandlerError turns an error returned by OnMessageReceived into a reply
+func (hub *Hub[S]) replyHandlerError(game *Game[S], player *Player, err error) {
+	if errors.Is(err, GameStopped) {
+		return
+	}
+
+	var clientError *ClientError
+
+	if errors.As(err, &clientError) {
+		logx.Logger.Info(
+			err.Error(),
+			zap.String("desc", "message is rejected"),
+			zap.String("gameId", game.Id),
+			zap.String("playerId", player.Id),
+		)
+
+		hub.ReplyError(game, player, clientError)
+		return
+	}
+
+	logx.Logger.Error(
+		err.Error(),
+		zap.String("desc", "could not handle incoming message"),
+		zap.String("gameId", game.Id),
+		zap.String("playerId", player.Id),
+	)
+
+	hub.ReplyError(game, player, InternalClientError)
+}
@@ -0,0 +1,161 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/AmirRezaM75/kenopsiarelay/pkg/logx"
+	"go.uber.org/zap"
+)
+
+// ExpiryPolicy configures when the hub ends and removes games by itself,
+// so long-running nodes do not keep games in memory forever.
+// Zero durations disable the corresponding policy.
+type ExpiryPolicy struct {
+	// PendingTTL removes pending games that nobody joined within the duration
+	PendingTTL time.Duration
+	// AbandonedAfter removes games whose human players are all disconnected for the duration
+	AbandonedAfter time.Duration
+	// MaxDuration removes unfinished games running longer than the duration since they
+	// started, or since they were created when StartGame is never called
+	MaxDuration time.Duration
+	// RemoveFinishedAfter removes finished games after the duration
+	RemoveFinishedAfter time.Duration
+	// SweepInterval is how often games are checked, it defaults to 30 seconds.
+	// It is also the precision of AbandonedAfter.
+	SweepInterval time.Duration
+}
+
+func (policy ExpiryPolicy) enabled() bool {
+	return policy.PendingTTL > 0 ||
+		policy.AbandonedAfter > 0 ||
+		policy.MaxDuration > 0 ||
+		policy.RemoveFinishedAfter > 0
+}
+
+// ExpiryReason tells which policy expired the game
+type ExpiryReason string
+
+const (
+	ExpiryReasonPendingTTL  ExpiryReason = "pending_ttl"
+	ExpiryReasonAbandoned   ExpiryReason = "abandoned"
+	ExpiryReasonMaxDuration ExpiryReason = "max_duration"
+	ExpiryReasonFinished    ExpiryReason = "finished"
+)
+
+// GameExpiredHandler is fired before an expired game is ended and removed
+type GameExpiredHandler[S GameState] func(hub *Hub[S], game *Game[S], reason ExpiryReason) error
+
+// sweep periodically applies the ExpiryPolicy until the hub is shut down
+func (hub *Hub[S]) sweep() {
+	interval := hub.ExpiryPolicy.SweepInterval
+
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-hub.ctx.Done():
+			return
+		case now := <-ticker.C:
+			hub.Games.Range(func(gameId string, game *Game[S]) bool {
+				if reason, expired := hub.expired(game, now); expired {
+					hub.expire(game, reason)
+				}
+				return true
+			})
+		}
+	}
+}
+
+// expired checks the game against each policy.
+// abandonedSince is only accessed by the sweeper goroutine.
+func (hub *Hub[S]) expired(game *Game[S], now time.Time) (ExpiryReason, bool) {
+	policy := hub.ExpiryPolicy
+
+	game.mutex.Lock()
+	status := game.status
+	startedAt := time.Unix(game.CreatedAt, 0)
+
+	if game.StartedAt != 0 {
+		startedAt = time.Unix(game.StartedAt, 0)
+	}
+
+	finishedAt := time.Unix(game.FinishedAt, 0)
+	game.mutex.Unlock()
+
+	humans, connected := 0, 0
+
+	game.Players.Range(func(playerId string, player *Player) bool {
+		if !player.IsBot {
+			humans++
+
+			if player.Connected() {
+				connected++
+			}
+		}
+		return true
+	})
+
+	if connected > 0 || humans == 0 {
+		game.abandonedSince = time.Time{}
+	} else if game.abandonedSince.IsZero() {
+		game.abandonedSince = now
+	}
+
+	switch status {
+	case GameStatusPending:
+		// A game whose players all refresh at once has been joined, it is left to AbandonedAfter
+		if policy.PendingTTL > 0 && !game.joined.Load() && now.Sub(time.Unix(game.CreatedAt, 0)) > policy.PendingTTL {
+			return ExpiryReasonPendingTTL, true
+		}
+	case GameStatusFinished:
+		if policy.RemoveFinishedAfter > 0 && now.Sub(finishedAt) > policy.RemoveFinishedAfter {
+			return ExpiryReasonFinished, true
+		}
+	}
+
+	if status != GameStatusFinished && policy.MaxDuration > 0 && now.Sub(startedAt) > policy.MaxDuration {
+		return ExpiryReasonMaxDuration, true
+	}
+
+	if policy.AbandonedAfter > 0 && !game.abandonedSince.IsZero() && now.Sub(game.abandonedSince) > policy.AbandonedAfter {
+		return ExpiryReasonAbandoned, true
+	}
+
+	return "", false
+}
+
+// expire fires OnGameExpired, finishes and removes the game
+func (hub *Hub[S]) expire(game *Game[S], reason ExpiryReason) {
+	logx.Logger.Info(
+		"game is expired",
+		zap.String("gameId", game.Id),
+		zap.String("reason", string(reason)),
+	)
+
+	hub.Metrics.GamesExpired.Inc(hub.GameSlug, string(reason))
+
+	if hub.OnGameExpired != nil {
+		err := hub.invoke(game, nil, "game_expired", func() error {
+			return hub.OnGameExpired(hub, game, reason)
+		})
+
+		if err != nil {
+			logx.Logger.Error(
+				err.Error(),
+				zap.String("desc", "could not execute handler when game is expired"),
+				zap.String("gameId", game.Id),
+				zap.String("reason", string(reason)),
+			)
+		}
+	}
+
+	// The error is ignored because a finished game has already published GameEndedEvent
+	_ = hub.FinishGame(game.Id)
+
+	hub.RemoveGame(game.Id)
+}
@@ -1,6 +1,15 @@
 package entities
 
-import "github.com/AmirRezaM75/kenopsiarelay/pkg/syncx"
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/AmirRezaM75/kenopsiarelay/pkg/syncx"
+)
 
 // GameState represents any game-specific state that can be stored in a game
 type GameState interface{}
@@ -8,13 +17,58 @@ type GameState interface{}
 // Game represents a game instance with generic state
 type Game[S GameState] struct {
 	Id        string
-	Status    string
 	CreatorId string
 	CreatedAt int64
-	LobbyId   string
-	State     S
+	// StartedAt and FinishedAt are unix timestamps of the first activation and the finish
+	StartedAt  int64
+	FinishedAt int64
+	LobbyId    string
+	State      S
 	// I used map[] in order to easily remove player and load it in O(1)
 	Players syncx.Map[string, *Player]
+	// Spectators receive messages marked as SpectatorVisible, see Hub.AddSpectator
+	Spectators syncx.Map[string, *Player]
+	// Seed is the seed of Rand, it is set by Hub.SeedGame or UseSeed
+	Seed int64
+	// TickOverruns counts ticks that took longer than their budget
+	TickOverruns atomic.Uint64
+
+	// ctx is cancelled when the game is removed or the hub shuts down
+	ctx    context.Context
+	cancel context.CancelFunc
+	// inbox is only created when Hub.SerializeGames is enabled.
+	// Every handler of the game is executed by a single goroutine reading from it.
+	inbox chan func()
+	// posted are the hooks queued by Hub.post, they are executed by the same goroutine
+	// as inbox, before the next task, and postedReady wakes it up
+	posted      []func()
+	postedReady chan struct{}
+	postedMutex sync.Mutex
+	// status is only changed by the hub, see Hub.StartGame and friends
+	status GameStatus
+	// stopTicking stops the tick loop, it is nil when the game is not active
+	stopTicking context.CancelFunc
+	mutex       sync.Mutex
+	// joined is set when the first human joins, see ExpiryPolicy.PendingTTL
+	joined atomic.Bool
+	// abandonedSince is when the sweeper first saw every human disconnected
+	abandonedSince time.Time
+	// banned holds ids of players removed with a ban, see Hub.RemovePlayer
+	banned syncx.Map[string, struct{}]
+	// random is the source of Rand, seeded with Seed
+	random *rand.Rand
+	// spectatorsMutex makes the MaxSpectators check and the attachment of a connection atomic
+	spectatorsMutex sync.Mutex
+}
+
+var GameStopped = errors.New("game is stopped")
+
+// Status returns the current lifecycle status of the game
+func (game *Game[S]) Status() GameStatus {
+	game.mutex.Lock()
+	defer game.mutex.Unlock()
+
+	return game.status
 }
 
 // GetPlayerIds returns a slice of all player IDs in the game
@@ -30,3 +84,54 @@ func (game *Game[S]) GetPlayerIds() []string {
 
 	return receiveIds
 }
+
+// loop executes the game's tasks one at a time until the game is stopped.
+// Posted hooks run before the next task, so a hook fired by a hub call
+// is executed after the handler which made the call and before anything else.
+func (game *Game[S]) loop() {
+	for {
+		game.runPosted()
+
+		select {
+		case <-game.ctx.Done():
+			game.runPosted()
+			return
+		case <-game.postedReady:
+		case task := <-game.inbox:
+			game.runPosted()
+			task()
+		}
+	}
+}
+
+// post queues the hook on the game's goroutine without waiting for it
+func (game *Game[S]) post(hook func()) {
+	game.postedMutex.Lock()
+	game.posted = append(game.posted, hook)
+	game.postedMutex.Unlock()
+
+	select {
+	case game.postedReady <- struct{}{}:
+	default:
+	}
+}
+
+// runPosted executes the posted hooks in order, including the ones they post
+func (game *Game[S]) runPosted() {
+	for {
+		game.postedMutex.Lock()
+
+		if len(game.posted) == 0 {
+			game.postedMutex.Unlock()
+			return
+		}
+
+		hook := game.posted[0]
+		game.posted[0] = nil
+		game.posted = game.posted[1:]
+
+		game.postedMutex.Unlock()
+
+		hook()
+	}
+}
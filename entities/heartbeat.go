@@ -0,0 +1,44 @@
+package entities
+
+import (
+	"encoding/binary"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	defaultPingInterval = 30 * time.Second
+	defaultPongTimeout  = 10 * time.Second
+	defaultWriteTimeout = 10 * time.Second
+)
+
+// RTT returns the round-trip time measured by the last ping, zero before the first pong
+func (player *Player) RTT() time.Duration {
+	return time.Duration(player.rtt.Load())
+}
+
+// ping writes a ping frame carrying the current time, which is echoed back in the pong
+func (player *Player) ping(connection *websocket.Conn, writeTimeout time.Duration) error {
+	payload := binary.BigEndian.AppendUint64(nil, uint64(time.Now().UnixNano()))
+
+	return connection.WriteControl(websocket.PingMessage, payload, time.Now().Add(writeTimeout))
+}
+
+// watchHeartbeat makes ReadMessage fail when no pong is received in time,
+// so a half-open TCP connection goes through the normal Kick and OnPlayerLeft path
+// instead of keeping the player "connected" for hours.
+func (player *Player) watchHeartbeat(connection *websocket.Conn, options ConnectionOptions) {
+	timeout := options.PingInterval + options.PongTimeout
+
+	_ = connection.SetReadDeadline(time.Now().Add(timeout))
+
+	connection.SetPongHandler(func(payload string) error {
+		if len(payload) == 8 {
+			sentAt := int64(binary.BigEndian.Uint64([]byte(payload)))
+			player.rtt.Store(time.Now().UnixNano() - sentAt)
+		}
+
+		return connection.SetReadDeadline(time.Now().Add(timeout))
+	})
+}
@@ -2,9 +2,16 @@ package entities
 
 import (
 	"context"
+	"hash/fnv"
+	"sync"
+	"time"
+
+	"github.com/AmirRezaM75/kenopsiarelay/codecs"
 	"github.com/AmirRezaM75/kenopsiarelay/pkg/logx"
+	"github.com/AmirRezaM75/kenopsiarelay/pkg/metricsx"
 	"github.com/AmirRezaM75/kenopsiarelay/pkg/syncx"
 	"github.com/AmirRezaM75/kenopsiarelay/schemas"
+	"github.com/gorilla/websocket"
 	"go.uber.org/zap"
 )
 
@@ -22,6 +29,72 @@ type HubConfig[S GameState] struct {
 	OnPlayerLeft       PlayerLeftHandler[S]
 	OnGameCreated      GameCreatedHandler[S]
 	GameStateFactory   func() S
+	// PlayerQueueSize is the capacity of each player's outbound queue
+	PlayerQueueSize int
+	// SlowConsumerPolicy is applied when a player's outbound queue is full
+	SlowConsumerPolicy SlowConsumerPolicy
+	// SlowConsumerCloseReason is sent in the close frame when SlowConsumerKick is applied
+	SlowConsumerCloseReason string
+	// DispatchShards is the number of dispatch goroutines, messages are routed by GameId
+	DispatchShards int
+	// SerializeGames runs every handler of a game on the game's own goroutine
+	SerializeGames bool
+	// GameInboxSize is the capacity of each game's inbox when SerializeGames is enabled
+	GameInboxSize int
+	// OnTick is called TickRate times per second for every game
+	OnTick   TickHandler[S]
+	TickRate int
+	// OnGameStatusChanged is fired on each lifecycle transition
+	OnGameStatusChanged GameStatusChangedHandler[S]
+	// ExpiryPolicy ends and removes games automatically
+	ExpiryPolicy  ExpiryPolicy
+	OnGameExpired GameExpiredHandler[S]
+	// ShutdownTimeout bounds the graceful shutdown started by Hub.Context cancellation
+	ShutdownTimeout time.Duration
+	// MetricsRegistry collects the hub's metrics, a new registry is created when nil
+	MetricsRegistry *metricsx.Registry
+	// ErrorEncoder encodes errors replied to clients, defaults to DefaultErrorEncoder
+	ErrorEncoder ErrorEncoder
+	// Middlewares wrap OnMessageReceived and the connection hooks of players, see MessageMiddleware
+	Middlewares []MessageMiddleware[S]
+	// PanicPolicy is applied after a game handler panics
+	PanicPolicy PanicPolicy
+	// Codecs supported by the hub in order of preference, defaults to JSON only
+	Codecs []codecs.Codec
+	// PingInterval, PongTimeout and WriteTimeout configure heartbeats of connections
+	PingInterval time.Duration
+	PongTimeout  time.Duration
+	WriteTimeout time.Duration
+	// MaxMessageSize is the maximum size of inbound messages in bytes, defaults to 64 KiB
+	MaxMessageSize int64
+	// InboundRateLimit limits inbound messages of each player
+	InboundRateLimit InboundRateLimit
+	// ReconnectGracePeriod delays OnPlayerLeft after a disconnect, zero fires it right away
+	ReconnectGracePeriod time.Duration
+	OnPlayerDisconnected PlayerDisconnectedHandler[S]
+	OnPlayerReconnected  PlayerReconnectedHandler[S]
+	// DuplicateConnectionPolicy is applied when a connected player joins again
+	DuplicateConnectionPolicy DuplicateConnectionPolicy
+	// AuthorizeSpectator enables spectating, MaxSpectators caps spectators of each game
+	AuthorizeSpectator SpectatorAuthorizer[S]
+	MaxSpectators      int
+	OnSpectatorJoined  SpectatorJoinedHandler[S]
+	OnSpectatorLeft    SpectatorLeftHandler[S]
+	// OnPlayerAdded and OnPlayerRemoved are fired by AddPlayer and RemovePlayer
+	OnPlayerAdded   PlayerAddedHandler[S]
+	OnPlayerRemoved PlayerRemovedHandler[S]
+	// BotFactory drives IsBot players, BotThinkDelay and BotThinkJitter delay their moves
+	BotFactory     BotFactory[S]
+	BotThinkDelay  time.Duration
+	BotThinkJitter time.Duration
+	// BotTakeoverAfter lets a bot of BotFactory play for a player disconnected for that long
+	BotTakeoverAfter time.Duration
+	OnBotTakeover    BotTakeoverHandler[S]
+	OnBotHandback    BotHandbackHandler[S]
+	// SeatingStrategy sets Index and TeamId of players, defaults to RandomSeating
+	SeatingStrategy SeatingStrategy
+	// SeedFactory seeds the random source of each game, defaults to the current time
+	SeedFactory SeedFactory
 }
 
 type Hub[S GameState] struct {
@@ -37,7 +110,12 @@ type Hub[S GameState] struct {
 	// and unpredictable behavior due to multiple copies of the same mutex.
 	// Using a pointer ensures that there is only one instance of the mutex,
 	// maintaining proper synchronization across all operations.
+	// Dispatch is the only way to the players, so the messages of a game keep their
+	// order whoever sends them. Prefer Send, which does not block after the shutdown.
 	Dispatch chan *schemas.DispatcherMessage
+	// shards are fed by Run from Dispatch. Every message of a game lands in the same shard,
+	// so a game keeps its ordering while different games are dispatched in parallel.
+	shards []*shard
 	// PublisherService for publishing game events to external systems
 	PublisherService PublisherService
 	// OnMessageReceived is responsible for processing incoming messages from connected clients.
@@ -52,6 +130,113 @@ type Hub[S GameState] struct {
 	OnGameCreated     GameCreatedHandler[S]
 	// GameStateFactory creates new game states
 	GameStateFactory func() S
+	// PlayerQueueSize is the capacity of each player's outbound queue
+	PlayerQueueSize int
+	// SlowConsumerPolicy decides what to do with a player that can not keep up with its messages.
+	// Delivery never blocks, so a stalled player can not freeze the dispatch loop.
+	SlowConsumerPolicy      SlowConsumerPolicy
+	SlowConsumerCloseReason string
+	// SerializeGames enables the actor mode. Each game owns a goroutine and an inbox,
+	// OnMessageReceived, OnPlayerJoined, OnPlayerLeft and OnGameCreated of the same game
+	// are executed on that goroutine one at a time, so handlers can mutate Game.State
+	// without any synchronization. Hooks fired by hub calls, e.g. OnGameStatusChanged
+	// after StartGame or OnPlayerAdded after AddPlayer, are queued on that goroutine
+	// without being waited for, so handlers can make these calls. A handler must not
+	// call a Handle* method of its own game, it would wait for itself.
+	SerializeGames bool
+	gameInboxSize  int
+	// OnTick is the fixed-timestep handler of real-time games. The framework owns the loop,
+	// so it is stopped on RemoveGame or context cancellation and never leaks.
+	OnTick TickHandler[S]
+	// TickRate is the number of ticks per second, zero disables the loop
+	TickRate int
+	// OnGameStatusChanged is fired after StartGame, PauseGame, ResumeGame, FinishGame and RemoveGame
+	OnGameStatusChanged GameStatusChangedHandler[S]
+	// ExpiryPolicy is applied by a sweeper started in Run.
+	// Each expired game fires OnGameExpired, then it is ended and removed.
+	ExpiryPolicy  ExpiryPolicy
+	OnGameExpired GameExpiredHandler[S]
+	// ShutdownTimeout is the deadline of the graceful shutdown when Hub.Context is cancelled
+	ShutdownTimeout time.Duration
+	// Metrics are always collected, exposing them is up to the caller
+	Metrics *Metrics
+	// ErrorEncoder encodes the reply sent to a player when OnMessageReceived fails.
+	// Return a *ClientError from the handler to tell the client why its message is rejected.
+	ErrorEncoder ErrorEncoder
+	// PanicPolicy is applied after a panic of a handler is recovered
+	PanicPolicy PanicPolicy
+	// Codecs are negotiated through the WebSocket subprotocol when a player joins.
+	// The first codec is used when the client does not ask for any.
+	Codecs []codecs.Codec
+	// PingInterval is how often players are pinged, a player whose pong does not
+	// arrive within PingInterval + PongTimeout is kicked and leaves the game.
+	PingInterval time.Duration
+	PongTimeout  time.Duration
+	// WriteTimeout is the deadline of each write to a player's connection
+	WriteTimeout time.Duration
+	// MaxMessageSize protects the node from huge messages, the connection is closed with 1009
+	MaxMessageSize int64
+	// InboundRateLimit is a per-player token bucket protecting OnMessageReceived from floods
+	InboundRateLimit InboundRateLimit
+	// ReconnectGracePeriod is how long a disconnected player is waited for.
+	// OnPlayerDisconnected is fired when the connection is lost, then either
+	// OnPlayerReconnected if the player joins again in time, or OnPlayerLeft.
+	ReconnectGracePeriod time.Duration
+	OnPlayerDisconnected PlayerDisconnectedHandler[S]
+	OnPlayerReconnected  PlayerReconnectedHandler[S]
+	// DuplicateConnectionPolicy decides between replacing, rejecting or keeping
+	// the previous connections of a player who joins again, e.g. from a second tab
+	DuplicateConnectionPolicy DuplicateConnectionPolicy
+	// AuthorizeSpectator is asked before a user joins a game as a spectator,
+	// spectating is disabled when it is nil. Spectators receive messages marked
+	// as SpectatorVisible and their input is rejected with SpectatorInput.
+	AuthorizeSpectator SpectatorAuthorizer[S]
+	// MaxSpectators is the maximum number of spectators of each game, zero means unlimited
+	MaxSpectators     int
+	OnSpectatorJoined SpectatorJoinedHandler[S]
+	OnSpectatorLeft   SpectatorLeftHandler[S]
+	// OnPlayerAdded is fired when a late joiner or a substitute is added by AddPlayer
+	OnPlayerAdded PlayerAddedHandler[S]
+	// OnPlayerRemoved is fired by RemovePlayer, OnPlayerLeft is not fired for removed players
+	OnPlayerRemoved PlayerRemovedHandler[S]
+	// BotFactory creates a Bot for each IsBot player when the game is added.
+	// Bots receive the messages dispatched to them and act through OnMessageReceived.
+	BotFactory BotFactory[S]
+	// BotThinkDelay is waited before each move of a bot, plus a random BotThinkJitter
+	BotThinkDelay  time.Duration
+	BotThinkJitter time.Duration
+	// BotTakeoverAfter is the opt-in timeout after which a bot created by BotFactory
+	// takes the seat of a disconnected player, zero disables it. The bot stops the
+	// reconnection grace period, so OnPlayerLeft is not fired while it plays, and
+	// it hands the seat back when the player joins again. It must be shorter than
+	// ReconnectGracePeriod, otherwise the player has already left and no bot takes over.
+	BotTakeoverAfter time.Duration
+	OnBotTakeover    BotTakeoverHandler[S]
+	OnBotHandback    BotHandbackHandler[S]
+	// SeatingStrategy seats the players copied from the lobby when a game is created, see SeatPlayers
+	SeatingStrategy SeatingStrategy
+	// SeedFactory returns the seed of each new game, see SeedGame.
+	// The seed is published in GameCreatedEvent, so FixedSeed can replay a game.
+	SeedFactory SeedFactory
+	// middlewares wrap OnMessageReceived and the connection hooks of players, see Use
+	middlewares []MessageMiddleware[S]
+
+	// ctx keeps the values of Context but not its cancellation, it is only cancelled
+	// once drain has finished, every game's context is derived from it.
+	ctx    context.Context
+	cancel context.CancelFunc
+	// quit is closed when the shutdown starts, dispatched is closed by Run after
+	// every message queued before it is delivered, see flushed. stopped is closed
+	// once the connections have exited, then Run closes the shards and returns.
+	quit       chan struct{}
+	dispatched chan struct{}
+	stopped    chan struct{}
+	flushed    sync.WaitGroup
+	// connections tracks Read and Write goroutines of the players
+	connections      sync.WaitGroup
+	connectionsMutex sync.Mutex
+	shutdownOnce     sync.Once
+	shutdownErr      error
 }
 
 // NewHub creates a new hub with context for lifecycle management
@@ -67,50 +252,359 @@ func NewHub[S GameState](config *HubConfig[S]) *Hub[S] {
 		bufferSize = 500
 	}
 
-	return &Hub[S]{
+	shardsCount := config.DispatchShards
+
+	if shardsCount <= 0 {
+		shardsCount = 1
+	}
+
+	shards := make([]*shard, shardsCount)
+
+	for i := range shards {
+		shards[i] = newShard()
+	}
+
+	queueSize := config.PlayerQueueSize
+
+	if queueSize <= 0 {
+		queueSize = defaultPlayerQueueSize
+	}
+
+	inboxSize := config.GameInboxSize
+
+	if inboxSize <= 0 {
+		inboxSize = 100
+	}
+
+	shutdownTimeout := config.ShutdownTimeout
+
+	if shutdownTimeout <= 0 {
+		shutdownTimeout = defaultShutdownTimeout
+	}
+
+	// Cancelling Context starts the shutdown, the games must keep running until drain stops them
+	ctx, cancel := context.WithCancel(context.WithoutCancel(config.Context))
+
+	errorEncoder := config.ErrorEncoder
+
+	if errorEncoder == nil {
+		errorEncoder = DefaultErrorEncoder
+	}
+
+	pingInterval := config.PingInterval
+
+	if pingInterval <= 0 {
+		pingInterval = defaultPingInterval
+	}
+
+	pongTimeout := config.PongTimeout
+
+	if pongTimeout <= 0 {
+		pongTimeout = defaultPongTimeout
+	}
+
+	writeTimeout := config.WriteTimeout
+
+	if writeTimeout <= 0 {
+		writeTimeout = defaultWriteTimeout
+	}
+
+	maxMessageSize := config.MaxMessageSize
+
+	if maxMessageSize <= 0 {
+		maxMessageSize = defaultMaxMessageSize
+	}
+
+	supportedCodecs := config.Codecs
+
+	if len(supportedCodecs) == 0 {
+		supportedCodecs = []codecs.Codec{codecs.JSON{}}
+	}
+
+	seatingStrategy := config.SeatingStrategy
+
+	if seatingStrategy == nil {
+		seatingStrategy = RandomSeating{}
+	}
+
+	seedFactory := config.SeedFactory
+
+	if seedFactory == nil {
+		seedFactory = timeSeed
+	}
+
+	if config.BotTakeoverAfter > 0 && config.ReconnectGracePeriod <= config.BotTakeoverAfter {
+		logx.Logger.Warn(
+			"bot takeover is unreachable",
+			zap.String("desc", "players leave before BotTakeoverAfter, ReconnectGracePeriod must be longer"),
+			zap.Duration("botTakeoverAfter", config.BotTakeoverAfter),
+			zap.Duration("reconnectGracePeriod", config.ReconnectGracePeriod),
+		)
+	}
+
+	closeReason := config.SlowConsumerCloseReason
+
+	if closeReason == "" {
+		closeReason = defaultSlowConsumerCloseReason
+	}
+
+	hub := &Hub[S]{
 		GameSlug:          config.GameSlug,
 		Context:           config.Context,
 		Dispatch:          make(chan *schemas.DispatcherMessage, bufferSize),
+		shards:            shards,
 		PublisherService:  config.PublisherService,
 		OnMessageReceived: config.OnMessageReceived,
 		OnPlayerJoined:    config.OnPlayerJoined,
 		OnPlayerLeft:      config.OnPlayerLeft,
 		OnGameCreated:     config.OnGameCreated,
 		GameStateFactory:  config.GameStateFactory,
+
+		PlayerQueueSize:         queueSize,
+		SlowConsumerPolicy:      config.SlowConsumerPolicy,
+		SlowConsumerCloseReason: closeReason,
+		SerializeGames:          config.SerializeGames,
+		gameInboxSize:           inboxSize,
+		OnTick:                  config.OnTick,
+		TickRate:                config.TickRate,
+		OnGameStatusChanged:     config.OnGameStatusChanged,
+		ExpiryPolicy:            config.ExpiryPolicy,
+		OnGameExpired:           config.OnGameExpired,
+		ShutdownTimeout:         shutdownTimeout,
+		ErrorEncoder:            errorEncoder,
+		PanicPolicy:             config.PanicPolicy,
+		Codecs:                  supportedCodecs,
+		PingInterval:            pingInterval,
+		PongTimeout:             pongTimeout,
+		WriteTimeout:            writeTimeout,
+		MaxMessageSize:          maxMessageSize,
+		InboundRateLimit:        config.InboundRateLimit,
+		ReconnectGracePeriod:    config.ReconnectGracePeriod,
+		OnPlayerDisconnected:    config.OnPlayerDisconnected,
+		OnPlayerReconnected:     config.OnPlayerReconnected,
+
+		DuplicateConnectionPolicy: config.DuplicateConnectionPolicy,
+		AuthorizeSpectator:        config.AuthorizeSpectator,
+		MaxSpectators:             config.MaxSpectators,
+		OnSpectatorJoined:         config.OnSpectatorJoined,
+		OnSpectatorLeft:           config.OnSpectatorLeft,
+		OnPlayerAdded:             config.OnPlayerAdded,
+		OnPlayerRemoved:           config.OnPlayerRemoved,
+		BotFactory:                config.BotFactory,
+		BotThinkDelay:             config.BotThinkDelay,
+		BotThinkJitter:            config.BotThinkJitter,
+		BotTakeoverAfter:          config.BotTakeoverAfter,
+		OnBotTakeover:             config.OnBotTakeover,
+		OnBotHandback:             config.OnBotHandback,
+		SeatingStrategy:           seatingStrategy,
+		SeedFactory:               seedFactory,
+
+		ctx:        ctx,
+		cancel:     cancel,
+		quit:       make(chan struct{}),
+		dispatched: make(chan struct{}),
+		stopped:    make(chan struct{}),
 	}
+
+	registry := config.MetricsRegistry
+
+	if registry == nil {
+		registry = metricsx.NewRegistry()
+	}
+
+	hub.Metrics = newMetrics(registry, hub)
+
+	hub.Use(config.Middlewares...)
+
+	return hub
 }
 
-// Run starts the hub's message dispatch loop with user-controlled graceful shutdown
-// When user cancels the context (e.g., on SIGTERM), hub shuts down gracefully
-// This ensures all player connections are closed and resources are cleaned up
+// Run starts the dispatch goroutines and routes messages from Dispatch to the shard of
+// their game. When the user cancels the context (e.g., on SIGTERM) the hub shuts down
+// gracefully: messages are still dispatched until every connection is closed, so hooks
+// fired by the shutdown can send, and Run returns once the last message is delivered.
 func (hub *Hub[S]) Run() {
+	var wg sync.WaitGroup
+
+	for _, shard := range hub.shards {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+			hub.dispatch(shard)
+		}()
+	}
+
+	if hub.ExpiryPolicy.enabled() {
+		go hub.sweep()
+	}
+
+	go hub.shutdownOnCancel()
+
+	quit := hub.quit
+
 	for {
 		select {
-		case <-hub.Context.Done():
-			hub.Games.Range(func(gameId string, game *Game[S]) bool {
-				game.Players.Range(func(playerId string, player *Player) bool {
-					player.Kick()
-					return true
-				})
-				return true
-			})
+		case <-quit:
+			// Messages queued before the shutdown are delivered before the close frames
+			hub.flushDispatch()
+
+			// A nil message is a barrier, each shard reaches it once the messages ahead are delivered
+			hub.flushed.Add(len(hub.shards))
+
+			for _, shard := range hub.shards {
+				shard.push(nil)
+			}
+
+			go func() {
+				hub.flushed.Wait()
+				close(hub.dispatched)
+			}()
+
+			quit = nil
+		case <-hub.stopped:
+			hub.flushDispatch()
+
+			for _, shard := range hub.shards {
+				shard.close()
+			}
+
+			wg.Wait()
 			return
 		case message := <-hub.Dispatch:
-			if game := hub.FindGame(message.GameId); game != nil {
-				for _, receiverId := range message.ReceiverIds {
-					if player, ok := game.Players.Load(receiverId); ok {
-						func() {
-							player.mutex.Lock()
-							defer player.mutex.Unlock()
-
-							if !player.IsClosed {
-								player.Message <- message.Body
-							}
-						}()
-					}
-				}
+			hub.route(message)
+		}
+	}
+}
+
+// flushDispatch routes the messages waiting in Dispatch without blocking on an empty channel
+func (hub *Hub[S]) flushDispatch() {
+	for {
+		select {
+		case message := <-hub.Dispatch:
+			hub.route(message)
+		default:
+			return
+		}
+	}
+}
+
+// Send pushes the message into Dispatch. Unlike a bare channel send, it drops the message
+// instead of blocking forever once the shutdown has stopped dispatching.
+func (hub *Hub[S]) Send(message *schemas.DispatcherMessage) {
+	if message == nil {
+		return
+	}
+
+	select {
+	case hub.Dispatch <- message:
+	case <-hub.stopped:
+		logx.Logger.Info(
+			"message is sent after the shutdown",
+			zap.String("desc", "dropping message"),
+			zap.String("gameId", message.GameId),
+		)
+	}
+}
+
+// route appends the message to the shard of its game, it never blocks
+func (hub *Hub[S]) route(message *schemas.DispatcherMessage) {
+	if message != nil {
+		hub.shardOf(message.GameId).push(message)
+	}
+}
+
+// dispatch delivers messages of a single shard to their receivers until the shard is closed
+func (hub *Hub[S]) dispatch(shard *shard) {
+	for {
+		<-shard.ready
+
+		for {
+			message, ok, closed := shard.pop()
+
+			if closed {
+				return
+			}
+
+			if !ok {
+				break
+			}
+
+			if message == nil {
+				hub.flushed.Done()
+				continue
 			}
+
+			hub.dispatchSafely(message)
+		}
+	}
+}
+
+// shardOf returns the shard responsible for the given game
+func (hub *Hub[S]) shardOf(gameId string) *shard {
+	if len(hub.shards) == 1 {
+		return hub.shards[0]
+	}
+
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte(gameId))
+
+	return hub.shards[hash.Sum32()%uint32(len(hub.shards))]
+}
+
+// deliver pushes the message into the outbound queue of each connection of the player
+// without blocking and applies the SlowConsumerPolicy when a queue is full.
+func (hub *Hub[S]) deliver(player *Player, message *schemas.DispatcherMessage) {
+	player.mutex.Lock()
+
+	delivered := false
+	dropped := player.DroppedMessages.Load()
+
+	var slow []*Connection
+
+	for _, connection := range player.connections {
+		if connection.closed {
+			continue
+		}
+
+		policy := hub.SlowConsumerPolicy
+
+		// Nothing would restart a kicked bot, it skips its oldest messages instead
+		if connection.Socket == nil && policy == SlowConsumerKick {
+			policy = SlowConsumerDropOldest
 		}
+
+		if player.enqueue(connection, Envelope{
+			Body:        message.Body,
+			CoalesceKey: message.CoalesceKey,
+			FrameType:   message.FrameType,
+		}, policy) {
+			delivered = true
+		} else {
+			slow = append(slow, connection)
+		}
+	}
+
+	dropped = player.DroppedMessages.Load() - dropped
+
+	player.mutex.Unlock()
+
+	if dropped > 0 {
+		hub.Metrics.MessagesDropped.Add(float64(dropped), hub.GameSlug)
+	} else if delivered {
+		hub.Metrics.MessagesSent.Inc(hub.GameSlug)
+	}
+
+	for _, connection := range slow {
+		logx.Logger.Warn(
+			"player outbound queue is full",
+			zap.String("desc", "kicking slow consumer"),
+			zap.String("gameId", message.GameId),
+			zap.String("playerId", player.Id),
+			zap.Uint64("droppedMessages", player.DroppedMessages.Load()),
+		)
+
+		player.kickConnection(connection, websocket.CloseTryAgainLater, hub.SlowConsumerCloseReason)
 	}
 }
 
@@ -119,6 +613,83 @@ type PlayerJoinedHandler[S GameState] func(hub *Hub[S], game *Game[S], player *P
 type PlayerLeftHandler[S GameState] func(hub *Hub[S], game *Game[S], player *Player) error
 type GameCreatedHandler[S GameState] func(hub *Hub[S], game *Game[S]) error
 
+// AddGame registers the game in the hub as pending, starts its goroutine in actor mode and its bots
+func (hub *Hub[S]) AddGame(game *Game[S]) {
+	game.ctx, game.cancel = context.WithCancel(hub.ctx)
+	game.status = GameStatusPending
+
+	hub.SeedGame(game)
+
+	if hub.SerializeGames {
+		game.inbox = make(chan func(), hub.gameInboxSize)
+		game.postedReady = make(chan struct{}, 1)
+		go game.loop()
+	}
+
+	hub.Games.Store(game.Id, game)
+
+	hub.startBots(game)
+}
+
+// execute runs the task on the game's goroutine and waits for its result.
+// Without actor mode the task is executed on the caller's goroutine.
+func (hub *Hub[S]) execute(game *Game[S], task func() error) error {
+	if game.inbox == nil {
+		return task()
+	}
+
+	result := make(chan error, 1)
+
+	select {
+	case game.inbox <- func() { result <- task() }:
+	case <-game.ctx.Done():
+		return GameStopped
+	}
+
+	select {
+	case err := <-result:
+		return err
+	case <-game.ctx.Done():
+		return GameStopped
+	}
+}
+
+// HandleMessage executes OnMessageReceived with the hub's concurrency guarantees
+func (hub *Hub[S]) HandleMessage(game *Game[S], player *Player, message []byte) error {
+	hub.Metrics.MessagesReceived.Inc(hub.GameSlug)
+
+	return hub.invoke(game, player, "message_received", func() error {
+		return hub.chain(hub.OnMessageReceived)(hub, game, player, message)
+	})
+}
+
+// HandlePlayerJoined executes OnPlayerJoined with the hub's concurrency guarantees
+func (hub *Hub[S]) HandlePlayerJoined(game *Game[S], player *Player) error {
+	game.joined.Store(true)
+
+	return hub.invoke(game, player, "player_joined", func() error {
+		return hub.chain(func(hub *Hub[S], game *Game[S], player *Player, _ []byte) error {
+			return hub.OnPlayerJoined(hub, game, player)
+		})(hub, game, player, nil)
+	})
+}
+
+// HandlePlayerLeft executes OnPlayerLeft with the hub's concurrency guarantees
+func (hub *Hub[S]) HandlePlayerLeft(game *Game[S], player *Player) error {
+	return hub.invoke(game, player, "player_left", func() error {
+		return hub.chain(func(hub *Hub[S], game *Game[S], player *Player, _ []byte) error {
+			return hub.OnPlayerLeft(hub, game, player)
+		})(hub, game, player, nil)
+	})
+}
+
+// HandleGameCreated executes OnGameCreated with the hub's concurrency guarantees
+func (hub *Hub[S]) HandleGameCreated(game *Game[S]) error {
+	return hub.invoke(game, nil, "game_created", func() error {
+		return hub.OnGameCreated(hub, game)
+	})
+}
+
 func (hub *Hub[S]) FindGame(id string) *Game[S] {
 	game, exists := hub.Games.Load(id)
 
@@ -132,11 +703,22 @@ func (hub *Hub[S]) FindGame(id string) *Game[S] {
 // RemoveGame removes a game from the hub to prevent memory leaks
 func (hub *Hub[S]) RemoveGame(gameId string) {
 	if game, exists := hub.Games.Load(gameId); exists {
+		// The error is ignored because a game can be removed in any status
+		_ = hub.transition(game, GameStatusRemoved)
+
 		game.Players.Range(func(playerId string, player *Player) bool {
 			player.Kick()
 			return true
 		})
+		game.Spectators.Range(func(spectatorId string, spectator *Player) bool {
+			spectator.Kick()
+			return true
+		})
 		hub.Games.Delete(gameId)
+
+		if game.cancel != nil {
+			game.cancel()
+		}
 	}
 }
 
@@ -145,7 +727,13 @@ func (hub *Hub[S]) EndGame(gameId, lobbyId string) {
 		return
 	}
 
-	message, err := schemas.GameEndedEvent(gameId, lobbyId, hub.GameSlug)
+	status := GameStatusFinished
+
+	if game := hub.FindGame(gameId); game != nil {
+		status = game.Status()
+	}
+
+	message, err := schemas.GameEndedEvent(gameId, lobbyId, hub.GameSlug, string(status))
 	if err != nil {
 		logx.Logger.Error("failed to create GameEndedEvent",
 			zap.String("gameId", gameId),
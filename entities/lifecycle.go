@@ -0,0 +1,185 @@
+package entities
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/AmirRezaM75/kenopsiarelay/pkg/logx"
+	"github.com/AmirRezaM75/kenopsiarelay/schemas"
+	"go.uber.org/zap"
+)
+
+// GameStatus is the lifecycle state of a game
+// pending → active ⇄ paused → finished → removed
+type GameStatus string
+
+const (
+	GameStatusPending  GameStatus = "pending"
+	GameStatusActive   GameStatus = "active"
+	GameStatusPaused   GameStatus = "paused"
+	GameStatusFinished GameStatus = "finished"
+	GameStatusRemoved  GameStatus = "removed"
+)
+
+// transitions lists the statuses reachable from each status.
+// A pending game may be finished without being started, e.g. when the lobby is abandoned.
+var transitions = map[GameStatus][]GameStatus{
+	GameStatusPending:  {GameStatusActive, GameStatusFinished, GameStatusRemoved},
+	GameStatusActive:   {GameStatusPaused, GameStatusFinished, GameStatusRemoved},
+	GameStatusPaused:   {GameStatusActive, GameStatusFinished, GameStatusRemoved},
+	GameStatusFinished: {GameStatusRemoved},
+}
+
+var (
+	GameNotFound          = errors.New("game not found")
+	InvalidGameTransition = errors.New("invalid game status transition")
+)
+
+// GameStatusChangedHandler is fired after each successful transition
+// with the same concurrency guarantees as OnMessageReceived.
+type GameStatusChangedHandler[S GameState] func(hub *Hub[S], game *Game[S], from, to GameStatus) error
+
+func (status GameStatus) canTransitionTo(to GameStatus) bool {
+	for _, allowed := range transitions[status] {
+		if allowed == to {
+			return true
+		}
+	}
+
+	return false
+}
+
+// StartGame moves a pending game to active and starts its tick loop
+func (hub *Hub[S]) StartGame(gameId string) error {
+	return hub.transitionById(gameId, GameStatusActive, GameStatusPending)
+}
+
+// PauseGame moves an active game to paused and stops its tick loop
+func (hub *Hub[S]) PauseGame(gameId string) error {
+	return hub.transitionById(gameId, GameStatusPaused, GameStatusActive)
+}
+
+// ResumeGame moves a paused game back to active
+func (hub *Hub[S]) ResumeGame(gameId string) error {
+	return hub.transitionById(gameId, GameStatusActive, GameStatusPaused)
+}
+
+// FinishGame moves the game to finished and publishes GameEndedEvent
+func (hub *Hub[S]) FinishGame(gameId string) error {
+	game := hub.FindGame(gameId)
+
+	if game == nil {
+		return GameNotFound
+	}
+
+	err := hub.transition(game, GameStatusFinished)
+
+	if err != nil {
+		return err
+	}
+
+	hub.EndGame(game.Id, game.LobbyId)
+
+	return nil
+}
+
+// transitionById requires the game to be in the expected status, so StartGame
+// can not resume a paused game and ResumeGame can not start a pending one.
+func (hub *Hub[S]) transitionById(gameId string, to, expected GameStatus) error {
+	game := hub.FindGame(gameId)
+
+	if game == nil {
+		return GameNotFound
+	}
+
+	if from := game.Status(); from != expected {
+		return fmt.Errorf("%w: %s -> %s", InvalidGameTransition, from, to)
+	}
+
+	return hub.transition(game, to)
+}
+
+// transition atomically changes the status, starts or stops the tick loop,
+// publishes GameStatusChangedEvent and fires OnGameStatusChanged
+func (hub *Hub[S]) transition(game *Game[S], to GameStatus) error {
+	game.mutex.Lock()
+
+	from := game.status
+
+	if !from.canTransitionTo(to) {
+		game.mutex.Unlock()
+		return fmt.Errorf("%w: %s -> %s", InvalidGameTransition, from, to)
+	}
+
+	game.status = to
+
+	switch to {
+	case GameStatusActive:
+		if game.StartedAt == 0 {
+			game.StartedAt = time.Now().Unix()
+		}
+
+		if game.stopTicking == nil && game.ctx != nil {
+			var ctx context.Context
+			ctx, game.stopTicking = context.WithCancel(game.ctx)
+			hub.startTicking(ctx, game)
+		}
+	case GameStatusPaused, GameStatusFinished, GameStatusRemoved:
+		if to == GameStatusFinished {
+			game.FinishedAt = time.Now().Unix()
+		}
+
+		if game.stopTicking != nil {
+			game.stopTicking()
+			game.stopTicking = nil
+		}
+	}
+
+	game.mutex.Unlock()
+
+	hub.publishStatusChanged(game, from, to)
+
+	if hub.OnGameStatusChanged == nil {
+		return nil
+	}
+
+	hub.post(game, nil, "game_status_changed", func() error {
+		return hub.OnGameStatusChanged(hub, game, from, to)
+	}, func(err error) {
+		logx.Logger.Error(
+			err.Error(),
+			zap.String("desc", "could not execute handler when game status is changed"),
+			zap.String("gameId", game.Id),
+			zap.String("from", string(from)),
+			zap.String("to", string(to)),
+		)
+	})
+
+	return nil
+}
+
+func (hub *Hub[S]) publishStatusChanged(game *Game[S], from, to GameStatus) {
+	if hub.PublisherService == nil {
+		return
+	}
+
+	message, err := schemas.GameStatusChangedEvent(game.Id, game.LobbyId, hub.GameSlug, string(from), string(to))
+	if err != nil {
+		logx.Logger.Error("failed to create GameStatusChangedEvent",
+			zap.String("gameId", game.Id),
+			zap.Error(err),
+		)
+
+		return
+	}
+
+	err = hub.PublisherService.Publish(message)
+	if err != nil {
+		logx.Logger.Error("failed to publish GameStatusChangedEvent",
+			zap.String("gameId", game.Id),
+			zap.Error(err),
+		)
+	}
+}
@@ -0,0 +1,160 @@
+package entities
+
+import (
+	"errors"
+	"time"
+
+	"github.com/AmirRezaM75/kenopsiarelay/pkg/metricsx"
+)
+
+const (
+	OutcomeOk    = "ok"
+	OutcomeError = "error"
+)
+
+// Metrics of the hub, every series is labeled with the game slug
+type Metrics struct {
+	Registry *metricsx.Registry
+
+	MessagesReceived *metricsx.Counter
+	MessagesSent     *metricsx.Counter
+	MessagesDropped  *metricsx.Counter
+	HandlerErrors    *metricsx.Counter
+	HandlerDuration  *metricsx.Histogram
+	TickOverruns     *metricsx.Counter
+	GamesExpired     *metricsx.Counter
+	Panics           *metricsx.Counter
+
+	MessagesTooBig      *metricsx.Counter
+	RateLimitViolations *metricsx.Counter
+}
+
+// newMetrics registers the hub's metrics, including gauges computed on each scrape
+func newMetrics[S GameState](registry *metricsx.Registry, hub *Hub[S]) *Metrics {
+	labels := metricsx.Labels{"game": hub.GameSlug}
+
+	registry.GaugeFunc("kenopsia_relay_games", "Number of games in the hub.", labels, func() float64 {
+		return float64(hub.Games.Len())
+	})
+
+	registry.GaugeFunc("kenopsia_relay_players", "Number of players in all games.", labels, func() float64 {
+		players, _ := hub.countPlayers()
+		return float64(players)
+	})
+
+	registry.GaugeFunc("kenopsia_relay_connected_players", "Number of players with an open connection.", labels, func() float64 {
+		_, connected := hub.countPlayers()
+		return float64(connected)
+	})
+
+	registry.GaugeFunc("kenopsia_relay_spectators", "Number of spectators in all games.", labels, func() float64 {
+		spectators := 0
+
+		hub.Games.Range(func(gameId string, game *Game[S]) bool {
+			spectators += game.Spectators.Len()
+			return true
+		})
+
+		return float64(spectators)
+	})
+
+	registry.GaugeFunc("kenopsia_relay_dispatch_queue_depth", "Number of messages waiting in Dispatch and its shards.", labels, func() float64 {
+		depth := len(hub.Dispatch)
+
+		for _, shard := range hub.shards {
+			depth += shard.len()
+		}
+
+		return float64(depth)
+	})
+
+	return &Metrics{
+		Registry: registry,
+		MessagesReceived: registry.Counter(
+			"kenopsia_relay_messages_received_total",
+			"Inbound messages received from players.",
+			"game",
+		),
+		MessagesSent: registry.Counter(
+			"kenopsia_relay_messages_sent_total",
+			"Outbound messages queued for players.",
+			"game",
+		),
+		MessagesDropped: registry.Counter(
+			"kenopsia_relay_messages_dropped_total",
+			"Outbound messages dropped by the slow consumer policy.",
+			"game",
+		),
+		HandlerErrors: registry.Counter(
+			"kenopsia_relay_handler_errors_total",
+			"Game handlers that returned an error.",
+			"game", "handler",
+		),
+		HandlerDuration: registry.Histogram(
+			"kenopsia_relay_handler_duration_seconds",
+			"Latency of game handlers.",
+			nil,
+			"game", "handler", "outcome",
+		),
+		TickOverruns: registry.Counter(
+			"kenopsia_relay_tick_overruns_total",
+			"Ticks that took longer than their budget.",
+			"game",
+		),
+		GamesExpired: registry.Counter(
+			"kenopsia_relay_games_expired_total",
+			"Games removed by the expiry policy.",
+			"game", "reason",
+		),
+		Panics: registry.Counter(
+			"kenopsia_relay_panics_total",
+			"Recovered panics of game handlers and dispatch loops.",
+			"game", "handler",
+		),
+		MessagesTooBig: registry.Counter(
+			"kenopsia_relay_messages_too_big_total",
+			"Connections closed because an inbound message exceeded the size limit.",
+			"game",
+		),
+		RateLimitViolations: registry.Counter(
+			"kenopsia_relay_rate_limit_violations_total",
+			"Inbound messages rejected by the per-player rate limit.",
+			"game", "policy",
+		),
+	}
+}
+
+func (hub *Hub[S]) countPlayers() (players, connected int) {
+	hub.Games.Range(func(gameId string, game *Game[S]) bool {
+		game.Players.Range(func(playerId string, player *Player) bool {
+			players++
+
+			if player.Connected() {
+				connected++
+			}
+			return true
+		})
+		return true
+	})
+
+	return players, connected
+}
+
+// observe records the latency and outcome of a handler.
+// GameStopped is not an error of the handler, it was never executed.
+func (hub *Hub[S]) observe(handler string, call func() error) error {
+	started := time.Now()
+
+	err := call()
+
+	outcome := OutcomeOk
+
+	if err != nil && !errors.Is(err, GameStopped) {
+		outcome = OutcomeError
+		hub.Metrics.HandlerErrors.Inc(hub.GameSlug, handler)
+	}
+
+	hub.Metrics.HandlerDuration.Observe(time.Since(started).Seconds(), hub.GameSlug, handler, outcome)
+
+	return err
+}
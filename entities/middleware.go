@@ -0,0 +1,140 @@
+package entities
+
+import (
+	"sync"
+	"time"
+
+	"github.com/AmirRezaM75/kenopsiarelay/pkg/logx"
+	"github.com/AmirRezaM75/kenopsiarelay/pkg/ratex"
+	"go.uber.org/zap"
+)
+
+// MessageMiddleware wraps OnMessageReceived in the style of chi middlewares.
+// The same chain wraps OnPlayerJoined, OnPlayerLeft, OnPlayerDisconnected and
+// OnPlayerReconnected, for those hooks the message is nil.
+type MessageMiddleware[S GameState] func(next MessageReceivedHandler[S]) MessageReceivedHandler[S]
+
+// Use appends middlewares to the chain, the first one is the outermost.
+// It is not safe to call Use while the hub is serving players.
+func (hub *Hub[S]) Use(middlewares ...MessageMiddleware[S]) {
+	hub.middlewares = append(hub.middlewares, middlewares...)
+}
+
+// chain wraps the handler with every registered middleware
+func (hub *Hub[S]) chain(handler MessageReceivedHandler[S]) MessageReceivedHandler[S] {
+	for i := len(hub.middlewares) - 1; i >= 0; i-- {
+		handler = hub.middlewares[i](handler)
+	}
+
+	return handler
+}
+
+// RecoverMiddleware recovers a panic of the next handler inside the chain, e.g. to let
+// outer middlewares see the error. The panic is handled exactly like the ones recovered
+// by the hub itself: it is logged, counted and the PanicPolicy is applied.
+func RecoverMiddleware[S GameState]() MessageMiddleware[S] {
+	return func(next MessageReceivedHandler[S]) MessageReceivedHandler[S] {
+		return func(hub *Hub[S], game *Game[S], player *Player, message []byte) (err error) {
+			defer func() {
+				if recovered := recover(); recovered != nil {
+					err = hub.panicked(game, player, "middleware", recovered)
+				}
+			}()
+
+			return next(hub, game, player, message)
+		}
+	}
+}
+
+// LoggerMiddleware logs every handled message with its size, duration and error
+func LoggerMiddleware[S GameState]() MessageMiddleware[S] {
+	return func(next MessageReceivedHandler[S]) MessageReceivedHandler[S] {
+		return func(hub *Hub[S], game *Game[S], player *Player, message []byte) error {
+			started := time.Now()
+
+			err := next(hub, game, player, message)
+
+			logx.Logger.Info(
+				"message is handled",
+				zap.String("gameId", game.Id),
+				zap.String("playerId", player.Id),
+				zap.Int("size", len(message)),
+				zap.Duration("duration", time.Since(started)),
+				zap.Error(err),
+			)
+
+			return err
+		}
+	}
+}
+
+// TimingMiddleware warns about handlers taking longer than the threshold
+func TimingMiddleware[S GameState](threshold time.Duration) MessageMiddleware[S] {
+	return func(next MessageReceivedHandler[S]) MessageReceivedHandler[S] {
+		return func(hub *Hub[S], game *Game[S], player *Player, message []byte) error {
+			started := time.Now()
+
+			err := next(hub, game, player, message)
+
+			if elapsed := time.Since(started); elapsed > threshold {
+				logx.Logger.Warn(
+					"slow handler",
+					zap.String("gameId", game.Id),
+					zap.String("playerId", player.Id),
+					zap.Duration("elapsed", elapsed),
+					zap.Duration("threshold", threshold),
+				)
+			}
+
+			return err
+		}
+	}
+}
+
+// RateLimited is replied to players sending more messages than allowed by RateLimitMiddleware
+var RateLimited = NewClientError("rate_limited", "Too many messages, slow down.")
+
+// RateLimitMiddleware allows each player rate messages per second with bursts of up to burst messages.
+// Rejected messages are replied with RateLimited. Join and leave hooks are never limited.
+func RateLimitMiddleware[S GameState](rate float64, burst int) MessageMiddleware[S] {
+	var (
+		mutex   sync.Mutex
+		buckets = map[*Player]*ratex.Bucket{}
+		purged  = time.Now()
+	)
+
+	bucketOf := func(hub *Hub[S], player *Player) *ratex.Bucket {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		// Forget players of removed games, otherwise buckets would leak
+		if time.Since(purged) > time.Minute {
+			for p := range buckets {
+				if hub.FindGame(p.GameId) == nil {
+					delete(buckets, p)
+				}
+			}
+
+			purged = time.Now()
+		}
+
+		bucket, exists := buckets[player]
+
+		if !exists {
+			bucket = ratex.NewBucket(rate, burst)
+			buckets[player] = bucket
+		}
+
+		return bucket
+	}
+
+	return func(next MessageReceivedHandler[S]) MessageReceivedHandler[S] {
+		return func(hub *Hub[S], game *Game[S], player *Player, message []byte) error {
+			if message != nil && !bucketOf(hub, player).Allow() {
+				return RateLimited
+			}
+
+			return next(hub, game, player, message)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package entities
+
+// Envelope is a single frame waiting in a player's outbound queue
+type Envelope struct {
+	Body []byte
+	// CoalesceKey identifies messages that supersede each other, e.g. "state" snapshots.
+	// It is only used by SlowConsumerCoalesce, empty means the message can not be coalesced.
+	CoalesceKey string
+	// FrameType is websocket.TextMessage or websocket.BinaryMessage, zero means binary
+	FrameType int
+}
+
+// SlowConsumerPolicy decides what happens when a player's outbound queue is full.
+// Hub.Run must never block on a single player, otherwise one client on a bad
+// connection would freeze the dispatch loop for every game on the node.
+type SlowConsumerPolicy int
+
+const (
+	// SlowConsumerKick closes the slow connection with Hub.SlowConsumerCloseReason.
+	// The client is expected to reconnect and receive a fresh state in OnPlayerJoined,
+	// which is why it is the default: no message is silently lost.
+	SlowConsumerKick SlowConsumerPolicy = iota
+	// SlowConsumerDropOldest discards the oldest queued message to make room for the new one
+	SlowConsumerDropOldest
+	// SlowConsumerDropNewest discards the message that does not fit in the queue
+	SlowConsumerDropNewest
+	// SlowConsumerCoalesce removes a queued message having the same CoalesceKey
+	// and appends the new one. If there is nothing to coalesce, the new message is dropped.
+	SlowConsumerCoalesce
+)
+
+const defaultPlayerQueueSize = 50
+
+const defaultSlowConsumerCloseReason = "connection is too slow, reconnect"
+
+// enqueue pushes the envelope into the outbound queue of the connection without blocking.
+// It returns false when the connection must be kicked according to the policy.
+// The caller must hold player.mutex and make sure the connection is not closed.
+func (player *Player) enqueue(connection *Connection, envelope Envelope, policy SlowConsumerPolicy) bool {
+	if len(connection.queue) < connection.options.QueueSize {
+		connection.push(envelope)
+		return true
+	}
+
+	switch policy {
+	case SlowConsumerDropOldest:
+		connection.queue[0] = Envelope{}
+		connection.queue = connection.queue[1:]
+		connection.push(envelope)
+
+		player.DroppedMessages.Add(1)
+	case SlowConsumerDropNewest:
+		player.DroppedMessages.Add(1)
+	case SlowConsumerCoalesce:
+		player.coalesce(connection, envelope)
+	default:
+		player.DroppedMessages.Add(1)
+		return false
+	}
+
+	return true
+}
+
+// coalesce removes the queued message with the same key and appends the new one.
+// Write takes messages under the same mutex, so the order is preserved.
+func (player *Player) coalesce(connection *Connection, envelope Envelope) {
+	if envelope.CoalesceKey != "" {
+		for i, queued := range connection.queue {
+			if queued.CoalesceKey == envelope.CoalesceKey {
+				connection.queue = append(connection.queue[:i], connection.queue[i+1:]...)
+				connection.push(envelope)
+				return
+			}
+		}
+	}
+
+	player.DroppedMessages.Add(1)
+}
+
+// dequeue takes the oldest message of the connection. When the queue is empty,
+// ok is false and closed tells whether the connection is closed.
+func (player *Player) dequeue(connection *Connection) (envelope Envelope, ok, closed bool) {
+	player.mutex.Lock()
+	defer player.mutex.Unlock()
+
+	if len(connection.queue) == 0 {
+		return Envelope{}, false, connection.closed
+	}
+
+	envelope = connection.queue[0]
+	connection.queue[0] = Envelope{}
+	connection.queue = connection.queue[1:]
+
+	return envelope, true, false
+}
+
+// push appends the envelope and wakes the consumer, the caller must hold player.mutex
+func (connection *Connection) push(envelope Envelope) {
+	connection.queue = append(connection.queue, envelope)
+	connection.wake()
+}
+
+// wake signals the consumer of the queue without blocking, a pending signal is enough
+func (connection *Connection) wake() {
+	select {
+	case connection.ready <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,106 @@
+package entities
+
+import (
+	"strconv"
+	"testing"
+)
+
+func envelope(sequence int, key string) Envelope {
+	return Envelope{Body: []byte(strconv.Itoa(sequence)), CoalesceKey: key}
+}
+
+func TestCoalesceReplacesQueuedMessage(t *testing.T) {
+	player := &Player{Id: "player"}
+	connection := NewConnection(nil, ConnectionOptions{QueueSize: 3})
+
+	player.mutex.Lock()
+	player.enqueue(connection, envelope(1, "state"), SlowConsumerCoalesce)
+	player.enqueue(connection, envelope(2, ""), SlowConsumerCoalesce)
+	player.enqueue(connection, envelope(3, ""), SlowConsumerCoalesce)
+	player.enqueue(connection, envelope(4, "state"), SlowConsumerCoalesce)
+	player.enqueue(connection, envelope(5, ""), SlowConsumerCoalesce)
+	player.mutex.Unlock()
+
+	var got []string
+
+	for {
+		message, ok, _ := player.dequeue(connection)
+
+		if !ok {
+			break
+		}
+
+		got = append(got, string(message.Body))
+	}
+
+	want := []string{"2", "3", "4"}
+
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+
+	if dropped := player.DroppedMessages.Load(); dropped != 1 {
+		t.Fatalf("got %d dropped messages, want 1", dropped)
+	}
+}
+
+func TestCoalesceKeepsOrderWhileWriting(t *testing.T) {
+	player := &Player{Id: "player"}
+	connection := NewConnection(nil, ConnectionOptions{QueueSize: 4})
+
+	done := make(chan []int)
+
+	go func() {
+		var received []int
+
+		for {
+			<-connection.ready
+
+			for {
+				message, ok, closed := player.dequeue(connection)
+
+				if closed {
+					done <- received
+					return
+				}
+
+				if !ok {
+					break
+				}
+
+				sequence, _ := strconv.Atoi(string(message.Body))
+				received = append(received, sequence)
+			}
+		}
+	}()
+
+	for sequence := 1; sequence <= 10000; sequence++ {
+		key := ""
+
+		if sequence%2 == 0 {
+			key = "state"
+		}
+
+		player.mutex.Lock()
+		player.enqueue(connection, envelope(sequence, key), SlowConsumerCoalesce)
+		player.mutex.Unlock()
+	}
+
+	player.mutex.Lock()
+	player.closeConnection(connection)
+	player.mutex.Unlock()
+
+	received := <-done
+
+	for i := 1; i < len(received); i++ {
+		if received[i] <= received[i-1] {
+			t.Fatalf("message %d is written after message %d", received[i], received[i-1])
+		}
+	}
+}
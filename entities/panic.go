@@ -0,0 +1,144 @@
+package entities
+
+import (
+	"errors"
+	"fmt"
+	"runtime/debug"
+
+	"github.com/AmirRezaM75/kenopsiarelay/pkg/logx"
+	"github.com/AmirRezaM75/kenopsiarelay/schemas"
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+)
+
+// PanicPolicy decides what happens after a game handler panics.
+// The panic is always recovered, logged and counted first,
+// so one broken game never takes down the other games of the node.
+type PanicPolicy int
+
+const (
+	// PanicIgnore only reports the panic, the call fails with HandlerPanicked
+	PanicIgnore PanicPolicy = iota
+	// PanicKickPlayer kicks the player whose message or hook caused the panic.
+	// Handlers without a player, e.g. ticks and timers, are ignored.
+	PanicKickPlayer
+	// PanicEndGame finishes and removes only the affected game
+	PanicEndGame
+)
+
+const panicCloseReason = "internal error"
+
+var HandlerPanicked = errors.New("handler panicked")
+
+// invoke runs a game handler with every guarantee of the hub:
+// metrics, actor mode serialization and panic isolation.
+// player is nil for handlers which are not caused by a player.
+func (hub *Hub[S]) invoke(game *Game[S], player *Player, handler string, call func() error) error {
+	return hub.observe(handler, func() error {
+		return hub.execute(game, func() error {
+			return hub.protect(game, player, handler, call)
+		})
+	})
+}
+
+// post fires a hook caused by a hub call, e.g. OnGameStatusChanged after StartGame,
+// with the same guarantees as invoke. In actor mode it is queued on the game's goroutine
+// instead of being waited for, so the hub call also works from a handler of the game.
+// failed is called with the error of the hook.
+func (hub *Hub[S]) post(game *Game[S], player *Player, handler string, call func() error, failed func(err error)) {
+	hook := func() {
+		err := hub.observe(handler, func() error {
+			return hub.protect(game, player, handler, call)
+		})
+
+		if err != nil {
+			failed(err)
+		}
+	}
+
+	if game.inbox == nil {
+		hook()
+		return
+	}
+
+	game.post(hook)
+}
+
+// protect recovers a panic of the call on the goroutine executing it,
+// which is the game's goroutine in actor mode
+func (hub *Hub[S]) protect(game *Game[S], player *Player, handler string, call func() error) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = hub.panicked(game, player, handler, recovered)
+		}
+	}()
+
+	return call()
+}
+
+// panicked reports a recovered panic, applies the PanicPolicy and returns the error
+// of the failed call. It must be called by the deferred function which recovered,
+// so the stack of the panic is still available.
+func (hub *Hub[S]) panicked(game *Game[S], player *Player, handler string, recovered any) error {
+	playerId := ""
+
+	if player != nil {
+		playerId = player.Id
+	}
+
+	logx.Logger.Error(
+		"handler panicked",
+		zap.Any("panic", recovered),
+		zap.String("handler", handler),
+		zap.String("gameId", game.Id),
+		zap.String("playerId", playerId),
+		zap.ByteString("stack", debug.Stack()),
+	)
+
+	hub.Metrics.Panics.Inc(hub.GameSlug, handler)
+
+	switch hub.PanicPolicy {
+	case PanicKickPlayer:
+		if player != nil {
+			go player.KickWithReason(websocket.CloseInternalServerErr, panicCloseReason)
+		}
+	case PanicEndGame:
+		// RemoveGame cancels the game's goroutine, which may be the current one
+		go func() {
+			// The error is ignored because a finished game has already published GameEndedEvent
+			_ = hub.FinishGame(game.Id)
+			hub.RemoveGame(game.Id)
+		}()
+	}
+
+	return fmt.Errorf("%w: %v", HandlerPanicked, recovered)
+}
+
+// dispatchSafely delivers a message and recovers any panic,
+// so the dispatch loop never dies because of one game
+func (hub *Hub[S]) dispatchSafely(message *schemas.DispatcherMessage) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			logx.Logger.Error(
+				"dispatch panicked",
+				zap.Any("panic", recovered),
+				zap.String("gameId", message.GameId),
+				zap.ByteString("stack", debug.Stack()),
+			)
+
+			hub.Metrics.Panics.Inc(hub.GameSlug, "dispatch")
+		}
+	}()
+
+	if game := hub.FindGame(message.GameId); game != nil {
+		for _, receiverId := range message.ReceiverIds {
+			if player, ok := game.Players.Load(receiverId); ok {
+				hub.deliver(player, message)
+			}
+		}
+
+		if message.SpectatorVisible {
+			hub.deliverSpectators(game, message)
+		}
+	}
+}
@@ -1,9 +1,15 @@
 package entities
 
 import (
+	"errors"
+	"net"
 	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/AmirRezaM75/kenopsiarelay/codecs"
 	"github.com/AmirRezaM75/kenopsiarelay/pkg/logx"
+	"github.com/AmirRezaM75/kenopsiarelay/pkg/ratex"
 	"github.com/gorilla/websocket"
 
 	"go.uber.org/zap"
@@ -13,154 +19,458 @@ type Player struct {
 	Id     string
 	GameId string
 	Index  int
+	// TeamId is set by the SeatingStrategy, zero means the game has no teams
+	TeamId int
 	// User data
 	Username    string
 	AvatarId    uint8
 	IsBot       bool
 	IsConnected bool
-	// To keep track of closed channel
-	IsClosed   bool
+	// IsSpectator players only receive SpectatorVisible messages and can not send any
+	IsSpectator bool
+	// Connection is the socket of the most recent connection, see Reconnect
 	Connection *websocket.Conn
-	Message    chan []byte
-	mutex      sync.Mutex
+	// DroppedMessages counts outbound messages discarded by the SlowConsumerPolicy
+	DroppedMessages atomic.Uint64
+	mutex           sync.Mutex
+	// connections are attached by Reconnect and detached when their Read loop exits.
+	// There is at most one, unless DuplicateConnectionAllowMultiple is used.
+	connections []*Connection
+	// codec is negotiated during the upgrade, see Hub.CodecOf
+	codec codecs.Codec
+	// rtt is the last measured round-trip time in nanoseconds, see RTT
+	rtt atomic.Int64
+	// RateLimitViolations counts inbound messages rejected by InboundRateLimit
+	RateLimitViolations atomic.Uint64
+	// bucket is the InboundRateLimit token bucket shared by every connection of the player
+	bucket *ratex.Bucket
+	// generation is incremented on each Reconnect, an OnPlayerLeft scheduled
+	// after a disconnect is skipped when it has changed in the meantime
+	generation uint64
+	// away is true between a disconnect and either a reconnect or OnPlayerLeft,
+	// see Hub.ReconnectGracePeriod
+	away           bool
+	disconnectedAt time.Time
+	reconnectCount int
+	graceTimer     *time.Timer
+	// epoch is incremented by Kick, it invalidates resume tokens issued before the kick
+	epoch uint64
+	// bot is the connection of the bot playing for the disconnected player, see Hub.BotTakeoverAfter
+	bot *Connection
 }
 
-// Different scenarios for 'close of closed channel'
-// 1) If user opens duplicate tab and close the first one
-
+// Kick closes every connection of the player, their Read loops exit
+// and the player goes through the disconnect and leave hooks.
+// Resume tokens issued before the kick are no longer accepted.
 func (player *Player) Kick() {
-	// We are using mutex to make sure IsClosed value is evaluated correctly
+	// We are using mutex to make sure closed value is evaluated correctly
 	// when reading its value at the same time.
-	// https://go101.org/article/channel-closing.html
 	player.mutex.Lock()
+	defer player.mutex.Unlock()
+
+	for _, connection := range player.connections {
+		player.closeConnection(connection)
+	}
+
+	player.IsConnected = false
+	player.epoch++
+}
+
+// Connected safely reads IsConnected
+func (player *Player) Connected() bool {
+	player.mutex.Lock()
+	defer player.mutex.Unlock()
+
+	return player.IsConnected
+}
+
+// Epoch changes every time the player is kicked, see Kick
+func (player *Player) Epoch() uint64 {
+	player.mutex.Lock()
+	defer player.mutex.Unlock()
 
+	return player.epoch
+}
+
+// Close gracefully disconnects the player. Unlike Kick, messages already in the
+// outbound queues are still written, followed by a close frame with the given code.
+func (player *Player) Close(code int, reason string) {
+	player.mutex.Lock()
 	defer player.mutex.Unlock()
 
-	if !player.IsClosed {
-		close(player.Message)
-		player.IsClosed = true
+	for _, connection := range player.connections {
+		if connection.closed {
+			continue
+		}
+
+		connection.closeCode = code
+		connection.closeReason = reason
+		connection.closed = true
+		connection.wake()
 	}
+}
 
-	// First we need to check if it's nil or not
-	// we call kick method in game_handler, and player may have no connection
-	if player.Connection != nil {
-		err := player.Connection.Close()
+// KickWithReason sends a close frame to the client before kicking the player,
+// so the client can distinguish being kicked from a network failure.
+func (player *Player) KickWithReason(code int, reason string) {
+	player.mutex.Lock()
+	sockets := make([]*websocket.Conn, 0, len(player.connections))
+
+	for _, connection := range player.connections {
+		if connection.Socket != nil {
+			sockets = append(sockets, connection.Socket)
+		}
+	}
+
+	player.mutex.Unlock()
+
+	for _, socket := range sockets {
+		// WriteControl is safe to be called concurrently with Write goroutine
+		err := socket.WriteControl(
+			websocket.CloseMessage,
+			closeMessage(code, reason),
+			time.Now().Add(time.Second),
+		)
 
 		if err != nil {
 			logx.Logger.Info(
 				err.Error(),
-				zap.String("desc", "could not close player connection"),
+				zap.String("desc", "could not write close message"),
 				zap.String("playerId", player.Id),
 			)
 		}
 	}
 
-	player.IsConnected = false
+	player.Kick()
 }
 
 // Reconnect safely handles player reconnection with proper mutex protection
 // This method prevents race conditions during player reconnection
-// by atomically updating all player state under mutex protection
-func (player *Player) Reconnect(connection *websocket.Conn) {
+// by atomically updating all player state under mutex protection.
+// Open connections of the player are handled by options.DuplicatePolicy.
+// It reports whether the player came back within the reconnection grace period
+// or took the seat back from a bot.
+func (player *Player) Reconnect(connection *Connection) (reconnected bool, err error) {
 	player.mutex.Lock()
-	defer player.mutex.Unlock()
 
-	// We don't use Kick() here because it would cause double-locking of the mutex
-	if !player.IsClosed {
-		close(player.Message)
-		player.IsClosed = true
+	// The bot playing for the player is stopped, it fires OnBotHandback on its way out
+	handedBack := player.bot != nil
+
+	if handedBack {
+		player.bot.handedBack = true
+		player.bot = nil
 	}
 
-	if player.Connection != nil {
-		_ = player.Connection.Close()
+	var open, replaced []*Connection
+
+	for _, attached := range player.connections {
+		if attached.closed || attached.handedBack {
+			// A kicked connection whose Read loop has not exited yet
+			replaced = append(replaced, attached)
+		} else {
+			open = append(open, attached)
+		}
 	}
 
-	player.Message = make(chan []byte, 50)
-	player.IsClosed = false
-	player.Connection = connection
+	if len(open) > 0 {
+		switch connection.options.DuplicatePolicy {
+		case DuplicateConnectionReject:
+			player.mutex.Unlock()
+			return false, DuplicateConnection
+		case DuplicateConnectionAllowMultiple:
+			if player.codec != nil && player.codec.Name() != connection.options.Codec.Name() {
+				player.mutex.Unlock()
+				return false, CodecMismatch
+			}
+		default:
+			replaced = append(replaced, open...)
+			open = nil
+		}
+	}
+
+	// Replaced connections are detached right away, so their Read loops
+	// exit without firing OnPlayerDisconnected for the new connection
+	player.connections = append(open, connection)
+
+	if len(open) == 0 {
+		player.codec = connection.options.Codec
+	}
+
+	player.Connection = connection.Socket
 	player.IsConnected = true
+	player.generation++
+
+	if player.away || handedBack {
+		if player.graceTimer != nil {
+			player.graceTimer.Stop()
+			player.graceTimer = nil
+		}
+
+		player.away = false
+		player.reconnectCount++
+		reconnected = true
+	}
+
+	player.mutex.Unlock()
+
+	for _, old := range replaced {
+		player.kickConnection(old, DuplicateConnectionCloseCode, DuplicateConnectionCloseReason)
+	}
+
+	return reconnected, nil
 }
 
-func (player *Player) Write() {
-	defer player.Kick()
+// Write flushes the outbound queue of the connection, it must run on its own goroutine
+// because gorilla/websocket supports only one concurrent writer per connection
+func (player *Player) Write(connection *Connection) {
+	defer func() {
+		player.mutex.Lock()
+		player.closeConnection(connection)
+		player.mutex.Unlock()
+	}()
 
-	for {
-		message, ok := <-player.Message
+	options := connection.options
 
-		if !ok {
-			logx.Logger.Info(
-				"player channel is closed!",
-				zap.String("playerId", player.Id),
-			)
-			return
-		}
+	// HEARTBEAT: Pings are written by this goroutine because
+	// gorilla/websocket supports only one concurrent writer
+	var pings <-chan time.Time
 
-		err := player.Connection.WriteMessage(websocket.BinaryMessage, message)
+	if options.PingInterval > 0 {
+		ticker := time.NewTicker(options.PingInterval)
+		defer ticker.Stop()
+		pings = ticker.C
+	}
 
-		if err != nil {
-			// Check if this is an unexpected connection error
-			// Also handle "use of closed network connection" which is not a WebSocket close error
-			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure, websocket.CloseNormalClosure) {
-				logx.Logger.Error(
+	for {
+		select {
+		case <-connection.ready:
+		case <-pings:
+			err := player.ping(connection.Socket, options.WriteTimeout)
+
+			if err != nil {
+				logx.Logger.Info(
 					err.Error(),
-					zap.String("desc", "unexpected websocket write error"),
+					zap.String("desc", "could not write ping message"),
 					zap.String("playerId", player.Id),
 				)
-			} else {
+				return
+			}
+
+			continue
+		}
+
+		for {
+			message, ok, closed := player.dequeue(connection)
+
+			if closed {
 				logx.Logger.Info(
-					err.Error(),
-					zap.String("error", err.Error()),
-					zap.String("desc", "expected websocket write error"),
+					"player channel is closed!",
 					zap.String("playerId", player.Id),
 				)
+
+				player.writeCloseMessage(connection)
+
+				return
+			}
+
+			if !ok {
+				break
+			}
+
+			if !player.writeMessage(connection, message) {
+				return
 			}
-			// Continuing the loop would cause infinite error logging if connection is broken
-			// Better to exit and let the connection cleanup happen
-			return
 		}
 	}
 }
 
-// unsubscribe is a generic function to unsubscribe a player from a hub
-func unsubscribe[S GameState](player *Player, hub *Hub[S]) {
-	if game := hub.FindGame(player.GameId); game != nil {
-		err := hub.OnPlayerLeft(hub, game, player)
+// writeMessage writes a single message and reports whether the connection is still usable
+func (player *Player) writeMessage(connection *Connection, message Envelope) bool {
+	frameType := message.FrameType
 
-		if err != nil {
+	if frameType == 0 {
+		frameType = websocket.BinaryMessage
+	}
+
+	if connection.options.WriteTimeout > 0 {
+		_ = connection.Socket.SetWriteDeadline(time.Now().Add(connection.options.WriteTimeout))
+	}
+
+	err := connection.Socket.WriteMessage(frameType, message.Body)
+
+	if err != nil {
+		// Check if this is an unexpected connection error
+		// Also handle "use of closed network connection" which is not a WebSocket close error
+		if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure, websocket.CloseNormalClosure) {
 			logx.Logger.Error(
 				err.Error(),
-				zap.String("desc", "could not execute handler when player is left"),
-				zap.String("gameId", game.Id),
+				zap.String("desc", "unexpected websocket write error"),
+				zap.String("playerId", player.Id),
+			)
+		} else {
+			logx.Logger.Info(
+				err.Error(),
+				zap.String("error", err.Error()),
+				zap.String("desc", "expected websocket write error"),
 				zap.String("playerId", player.Id),
 			)
-			return
 		}
+		// Continuing the loop would cause infinite error logging if connection is broken
+		// Better to exit and let the connection cleanup happen
+		return false
+	}
+
+	return true
+}
+
+// writeCloseMessage writes the close frame requested by Close, if any
+func (player *Player) writeCloseMessage(connection *Connection) {
+	player.mutex.Lock()
+	code, reason := connection.closeCode, connection.closeReason
+	player.mutex.Unlock()
+
+	if code == 0 {
+		return
+	}
+
+	err := connection.Socket.WriteControl(
+		websocket.CloseMessage,
+		closeMessage(code, reason),
+		time.Now().Add(time.Second),
+	)
+
+	if err != nil {
+		logx.Logger.Info(
+			err.Error(),
+			zap.String("desc", "could not write close message"),
+			zap.String("playerId", player.Id),
+		)
+	}
+}
+
+// unsubscribe is a generic function to unsubscribe a player from a hub.
+// OnPlayerDisconnected is fired right away, OnPlayerLeft only after the
+// reconnection grace period passes without the player coming back.
+func unsubscribe[S GameState](player *Player, hub *Hub[S], generation uint64) {
+	game := hub.FindGame(player.GameId)
+
+	if game == nil {
+		return
+	}
+
+	// Spectators have no seat to keep, so there is no grace period
+	if player.IsSpectator {
+		hub.removeSpectator(game, player)
+		return
+	}
+
+	// OnPlayerRemoved has been fired instead
+	if !game.isSeated(player) {
+		return
+	}
+
+	err := hub.HandlePlayerDisconnected(game, player)
+
+	if err != nil {
+		logx.Logger.Error(
+			err.Error(),
+			zap.String("desc", "could not execute handler when player is disconnected"),
+			zap.String("gameId", game.Id),
+			zap.String("playerId", player.Id),
+		)
+	}
+
+	hub.scheduleTakeover(game, player, generation)
+
+	if hub.ReconnectGracePeriod <= 0 {
+		leave(player, hub, game, generation)
+		return
+	}
+
+	player.mutex.Lock()
+	defer player.mutex.Unlock()
+
+	// The player may have already reconnected while OnPlayerDisconnected was running
+	if player.generation == generation && player.away {
+		player.graceTimer = time.AfterFunc(hub.ReconnectGracePeriod, func() {
+			leave(player, hub, game, generation)
+		})
+	}
+}
+
+// leave fires OnPlayerLeft unless the player has reconnected in the meantime
+func leave[S GameState](player *Player, hub *Hub[S], game *Game[S], generation uint64) {
+	player.mutex.Lock()
+	left := player.generation == generation && player.away
+
+	if left {
+		player.away = false
+		player.graceTimer = nil
+	}
+	player.mutex.Unlock()
+
+	// The hub is shut down or the game is removed, there is nobody to leave
+	if !left || game.ctx.Err() != nil || !game.isSeated(player) {
+		return
+	}
+
+	err := hub.HandlePlayerLeft(game, player)
+
+	if err != nil {
+		logx.Logger.Error(
+			err.Error(),
+			zap.String("desc", "could not execute handler when player is left"),
+			zap.String("gameId", game.Id),
+			zap.String("playerId", player.Id),
+		)
 	}
 }
 
-func Read[S GameState](player *Player, hub *Hub[S]) {
+// Read is the blocking read loop of a connection, the player is unsubscribed
+// once its last connection is closed
+func Read[S GameState](player *Player, connection *Connection, hub *Hub[S]) {
 	defer func() {
-		player.Kick()
-		unsubscribe(player, hub)
+		if last, generation := player.detach(connection, true); last {
+			unsubscribe(player, hub, generation)
+		}
 	}()
 
-	for {
-		// CONTEXT CANCELLATION FIX: Check if context is cancelled before each read attempt
-		// This ensures the goroutine can exit gracefully when user shuts down the server
-		// We use select with default to make this non-blocking
-		select {
-		case <-hub.Context.Done():
-			// Context cancelled - shutdown gracefully
-			return
-		default:
-			// Continue to read
-		}
+	options := connection.options
+
+	if options.PingInterval > 0 {
+		player.watchHeartbeat(connection.Socket, options)
+	}
+
+	// SIZE LIMIT: gorilla/websocket closes the connection with 1009 (message too big)
+	if options.MaxMessageSize > 0 {
+		connection.Socket.SetReadLimit(options.MaxMessageSize)
+	}
 
-		_, message, err := player.Connection.ReadMessage()
+	bucket := player.inboundBucket(options.RateLimit)
+
+	for {
+		_, message, err := connection.Socket.ReadMessage()
 
 		if err != nil {
+			var netError net.Error
+
 			// This logs only unexpected errors, not normal browser refresh/close events
-			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure, websocket.CloseNormalClosure) {
+			if errors.Is(err, websocket.ErrReadLimit) {
+				hub.Metrics.MessagesTooBig.Inc(hub.GameSlug)
+
+				logx.Logger.Warn(
+					err.Error(),
+					zap.String("desc", "inbound message exceeds the size limit"),
+					zap.String("playerId", player.Id),
+					zap.Int64("limit", options.MaxMessageSize),
+				)
+			} else if errors.As(err, &netError) && netError.Timeout() {
+				logx.Logger.Info(
+					err.Error(),
+					zap.String("desc", "no pong received, connection is dead"),
+					zap.String("playerId", player.Id),
+				)
+			} else if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure, websocket.CloseNormalClosure) {
 				logx.Logger.Error(
 					err.Error(),
 					zap.String("desc", "unexpected websocket close error"),
@@ -176,7 +486,21 @@ func Read[S GameState](player *Player, hub *Hub[S]) {
 			return
 		}
 
-		react(player, message, hub)
+		// GRACEFUL SHUTDOWN: Once the hub is draining, inbound messages are ignored
+		// We keep reading until Write flushes the queue and closes the connection
+		if hub.Draining() {
+			continue
+		}
+
+		allowed, kicked := hub.allowInbound(player, bucket)
+
+		if kicked {
+			return
+		}
+
+		if allowed {
+			react(player, message, hub)
+		}
 	}
 }
 
@@ -188,15 +512,15 @@ func react[S GameState](player *Player, message []byte, hub *Hub[S]) {
 		return
 	}
 
-	err := hub.OnMessageReceived(hub, game, player, message)
+	if player.IsSpectator {
+		hub.ReplyError(game, player, SpectatorInput)
+		return
+	}
+
+	err := hub.HandleMessage(game, player, message)
 
 	if err != nil {
-		logx.Logger.Error(
-			err.Error(),
-			zap.String("desc", "could not handle incoming message"),
-			zap.String("gameId", game.Id),
-			zap.String("playerId", player.Id),
-		)
+		hub.replyHandlerError(game, player, err)
 		return
 	}
 }
@@ -0,0 +1,48 @@
+package entities
+
+import (
+	"math/rand"
+	"time"
+
+	"github.com/AmirRezaM75/kenopsiarelay/pkg/randx"
+)
+
+// SeedFactory returns the seed of the random source of a new game
+type SeedFactory func() int64
+
+// FixedSeed makes every game use the same seed, e.g. in tests or to replay a recorded game
+func FixedSeed(seed int64) SeedFactory {
+	return func() int64 { return seed }
+}
+
+func timeSeed() int64 {
+	return time.Now().UnixNano()
+}
+
+// Rand is the random source of the game, use it for shuffles, dice and any other
+// randomness of the rules so a game can be replayed from its Seed. It is safe for
+// concurrent use, but the sequence is only reproducible when the calls happen in
+// the same order, e.g. in actor mode. It is nil until the game is seeded.
+func (game *Game[S]) Rand() *rand.Rand {
+	return game.random
+}
+
+// UseSeed makes the game use the given seed instead of one of the hub's SeedFactory,
+// e.g. the seed of a recorded game to replay it. Any seed, zero included, is kept
+// by SeedGame, so call it before the game is created or added to the hub.
+func (game *Game[S]) UseSeed(seed int64) {
+	game.Seed = seed
+	game.random = randx.New(seed)
+}
+
+// SeedGame creates the random source of the game from a seed of the hub's SeedFactory.
+// A game which is already seeded, e.g. by UseSeed, is left untouched. AddGame seeds
+// the game, call it earlier to use Game.Rand before, e.g. for seating.
+func (hub *Hub[S]) SeedGame(game *Game[S]) {
+	if game.random != nil {
+		return
+	}
+
+	game.Seed = hub.SeedFactory()
+	game.random = randx.New(game.Seed)
+}
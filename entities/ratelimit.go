@@ -0,0 +1,104 @@
+package entities
+
+import (
+	"github.com/AmirRezaM75/kenopsiarelay/pkg/logx"
+	"github.com/AmirRezaM75/kenopsiarelay/pkg/ratex"
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+)
+
+const defaultMaxMessageSize = 64 * 1024
+
+// RateLimitPolicy decides what happens to a message exceeding the player's inbound rate
+type RateLimitPolicy int
+
+const (
+	// RateLimitDrop silently drops the message
+	RateLimitDrop RateLimitPolicy = iota
+	// RateLimitWarn drops the message and replies RateLimited to the player
+	RateLimitWarn
+	// RateLimitKick warns the player and kicks it after MaxViolations violations
+	RateLimitKick
+)
+
+func (policy RateLimitPolicy) String() string {
+	switch policy {
+	case RateLimitWarn:
+		return "warn"
+	case RateLimitKick:
+		return "kick"
+	default:
+		return "drop"
+	}
+}
+
+const rateLimitCloseReason = "rate limit exceeded"
+
+// InboundRateLimit is a token bucket applied to inbound messages of each player
+type InboundRateLimit struct {
+	// Rate is the number of messages allowed per second, zero disables the limit
+	Rate float64
+	// Burst is the number of messages allowed at once
+	Burst  int
+	Policy RateLimitPolicy
+	// MaxViolations is the number of violations tolerated by RateLimitKick
+	MaxViolations uint64
+}
+
+func (limit InboundRateLimit) bucket() *ratex.Bucket {
+	if limit.Rate <= 0 {
+		return nil
+	}
+
+	return ratex.NewBucket(limit.Rate, limit.Burst)
+}
+
+// inboundBucket returns the token bucket of the player. It is created by the first
+// connection and kept afterwards, so neither reconnecting nor opening more
+// connections with DuplicateConnectionAllowMultiple grants more messages.
+func (player *Player) inboundBucket(limit InboundRateLimit) *ratex.Bucket {
+	player.mutex.Lock()
+	defer player.mutex.Unlock()
+
+	if player.bucket == nil {
+		player.bucket = limit.bucket()
+	}
+
+	return player.bucket
+}
+
+// allowInbound takes a token from the player's bucket and applies the policy
+// on violation. It returns false when the message must be dropped and
+// kicked is true when the player has been kicked.
+func (hub *Hub[S]) allowInbound(player *Player, bucket *ratex.Bucket) (allowed, kicked bool) {
+	if bucket == nil || bucket.Allow() {
+		return true, false
+	}
+
+	violations := player.RateLimitViolations.Add(1)
+
+	policy := hub.InboundRateLimit.Policy
+
+	hub.Metrics.RateLimitViolations.Inc(hub.GameSlug, policy.String())
+
+	logx.Logger.Warn(
+		"inbound rate limit exceeded",
+		zap.String("gameId", player.GameId),
+		zap.String("playerId", player.Id),
+		zap.Uint64("violations", violations),
+		zap.String("policy", policy.String()),
+	)
+
+	if policy == RateLimitKick && violations > hub.InboundRateLimit.MaxViolations {
+		player.KickWithReason(websocket.ClosePolicyViolation, rateLimitCloseReason)
+		return false, true
+	}
+
+	if policy == RateLimitWarn || policy == RateLimitKick {
+		if game := hub.FindGame(player.GameId); game != nil {
+			hub.ReplyError(game, player, RateLimited)
+		}
+	}
+
+	return false, false
+}
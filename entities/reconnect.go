@@ -0,0 +1,55 @@
+package entities
+
+import "time"
+
+// PlayerDisconnectedHandler is fired as soon as the connection of a player is lost.
+// The player may still come back within the ReconnectGracePeriod, so unlike
+// OnPlayerLeft this is the place to pause a turn timer rather than to forfeit.
+type PlayerDisconnectedHandler[S GameState] func(hub *Hub[S], game *Game[S], player *Player) error
+
+// PlayerReconnectedHandler is fired instead of OnPlayerJoined when a player
+// joins again within the ReconnectGracePeriod, e.g. after a browser refresh.
+type PlayerReconnectedHandler[S GameState] func(hub *Hub[S], game *Game[S], player *Player) error
+
+// DisconnectedAt is when the player lost its last connection, zero if it never did
+func (player *Player) DisconnectedAt() time.Time {
+	player.mutex.Lock()
+	defer player.mutex.Unlock()
+
+	return player.disconnectedAt
+}
+
+// ReconnectCount is how many times the player came back within the grace period
+func (player *Player) ReconnectCount() int {
+	player.mutex.Lock()
+	defer player.mutex.Unlock()
+
+	return player.reconnectCount
+}
+
+// HandlePlayerDisconnected executes OnPlayerDisconnected with the hub's concurrency guarantees
+func (hub *Hub[S]) HandlePlayerDisconnected(game *Game[S], player *Player) error {
+	if hub.OnPlayerDisconnected == nil {
+		return nil
+	}
+
+	return hub.invoke(game, player, "player_disconnected", func() error {
+		return hub.chain(func(hub *Hub[S], game *Game[S], player *Player, _ []byte) error {
+			return hub.OnPlayerDisconnected(hub, game, player)
+		})(hub, game, player, nil)
+	})
+}
+
+// HandlePlayerReconnected executes OnPlayerReconnected with the hub's concurrency guarantees.
+// Without OnPlayerReconnected the player is treated as joined, so the game still sends it a fresh state.
+func (hub *Hub[S]) HandlePlayerReconnected(game *Game[S], player *Player) error {
+	if hub.OnPlayerReconnected == nil {
+		return hub.HandlePlayerJoined(game, player)
+	}
+
+	return hub.invoke(game, player, "player_reconnected", func() error {
+		return hub.chain(func(hub *Hub[S], game *Game[S], player *Player, _ []byte) error {
+			return hub.OnPlayerReconnected(hub, game, player)
+		})(hub, game, player, nil)
+	})
+}
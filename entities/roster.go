@@ -0,0 +1,164 @@
+package entities
+
+import (
+	"errors"
+
+	"github.com/AmirRezaM75/kenopsiarelay/pkg/logx"
+	"github.com/amirrezam75/kenopsiauser"
+	"go.uber.org/zap"
+)
+
+// Close codes sent to a player removed by RemovePlayer, clients should not
+// reconnect automatically when they receive one of them.
+const (
+	RemovedCloseCode = 4002
+	BannedCloseCode  = 4003
+)
+
+var (
+	PlayerNotFound = errors.New("player not found")
+	PlayerExists   = errors.New("player is already in the game")
+	PlayerBanned   = errors.New("player is banned from the game")
+	SeatTaken      = errors.New("seat is taken by another player")
+)
+
+// PlayerAddedHandler is fired after AddPlayer, the player is not connected yet
+type PlayerAddedHandler[S GameState] func(hub *Hub[S], game *Game[S], player *Player) error
+
+// PlayerRemovedHandler is fired after RemovePlayer instead of OnPlayerLeft
+type PlayerRemovedHandler[S GameState] func(hub *Hub[S], game *Game[S], player *Player, reason string, banned bool) error
+
+// IsBanned reports whether the user is banned by RemovePlayer
+func (game *Game[S]) IsBanned(userId string) bool {
+	_, banned := game.banned.Load(userId)
+
+	return banned
+}
+
+// AddPlayer adds a late joiner or a substitute to a running game. Seat is the
+// player's Index, zero takes the seat after the last one, and teamId is its TeamId,
+// zero when the game has no teams. The user joins through GameService.Join like
+// the players copied from the lobby. OnPlayerAdded runs with the hub's concurrency
+// guarantees, AddPlayer can be called from handlers as well.
+func (hub *Hub[S]) AddPlayer(gameId string, user kenopsiauser.User, seat, teamId int) (*Player, error) {
+	game := hub.FindGame(gameId)
+
+	if game == nil {
+		return nil, GameNotFound
+	}
+
+	if game.IsBanned(user.Id) {
+		return nil, PlayerBanned
+	}
+
+	game.mutex.Lock()
+
+	if _, exists := game.Players.Load(user.Id); exists {
+		game.mutex.Unlock()
+		return nil, PlayerExists
+	}
+
+	last, taken := 0, false
+
+	game.Players.Range(func(playerId string, player *Player) bool {
+		last = max(last, player.Index)
+		taken = taken || player.Index == seat
+		return true
+	})
+
+	if taken {
+		game.mutex.Unlock()
+		return nil, SeatTaken
+	}
+
+	if seat <= 0 {
+		seat = last + 1
+	}
+
+	player := &Player{
+		Id:       user.Id,
+		GameId:   game.Id,
+		Index:    seat,
+		TeamId:   teamId,
+		Username: user.Username,
+		AvatarId: user.AvatarId,
+	}
+
+	game.Players.Store(player.Id, player)
+
+	game.mutex.Unlock()
+
+	if hub.OnPlayerAdded != nil {
+		hub.post(game, player, "player_added", func() error {
+			return hub.OnPlayerAdded(hub, game, player)
+		}, func(err error) {
+			logx.Logger.Error(
+				err.Error(),
+				zap.String("desc", "could not execute handler when player is added"),
+				zap.String("gameId", game.Id),
+				zap.String("playerId", player.Id),
+			)
+		})
+	}
+
+	return player, nil
+}
+
+// RemovePlayer removes the player from the game and closes its connections with
+// the reason, using BannedCloseCode when ban is true. The reason is truncated to 123 bytes
+// to fit in the close frame. A banned player is refused by GameService.Join and AddPlayer
+// for the rest of the game. OnPlayerLeft is not fired, OnPlayerRemoved is fired instead
+// with the hub's concurrency guarantees.
+func (hub *Hub[S]) RemovePlayer(gameId, playerId, reason string, ban bool) error {
+	game := hub.FindGame(gameId)
+
+	if game == nil {
+		return GameNotFound
+	}
+
+	game.mutex.Lock()
+
+	player, exists := game.Players.Load(playerId)
+
+	if exists {
+		game.Players.Delete(playerId)
+	}
+
+	game.mutex.Unlock()
+
+	if !exists {
+		return PlayerNotFound
+	}
+
+	code := RemovedCloseCode
+
+	if ban {
+		game.banned.Store(playerId, struct{}{})
+		code = BannedCloseCode
+	}
+
+	player.KickWithReason(code, reason)
+
+	if hub.OnPlayerRemoved != nil {
+		hub.post(game, player, "player_removed", func() error {
+			return hub.OnPlayerRemoved(hub, game, player, reason, ban)
+		}, func(err error) {
+			logx.Logger.Error(
+				err.Error(),
+				zap.String("desc", "could not execute handler when player is removed"),
+				zap.String("gameId", game.Id),
+				zap.String("playerId", player.Id),
+			)
+		})
+	}
+
+	return nil
+}
+
+// isSeated reports whether the player is still in the game, RemovePlayer
+// deletes it before its connections are closed
+func (game *Game[S]) isSeated(player *Player) bool {
+	seated, exists := game.Players.Load(player.Id)
+
+	return exists && seated == player
+}
@@ -0,0 +1,141 @@
+package entities
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/AmirRezaM75/kenopsiarelay/pkg/logx"
+	"go.uber.org/zap"
+)
+
+// TimerHandler is executed by game-scoped timers with the same concurrency
+// guarantees as OnMessageReceived, so it may mutate Game.State in actor mode.
+type TimerHandler[S GameState] func(hub *Hub[S], game *Game[S]) error
+
+// Timer is a cancellable handle of a scheduled callback.
+// Timers are cancelled automatically when the game is removed or the hub shuts down.
+type Timer struct {
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// Stop cancels the timer. A callback that is already running is not interrupted,
+// but a callback waiting for the game's goroutine is skipped.
+func (timer *Timer) Stop() {
+	timer.stopOnce.Do(func() {
+		close(timer.stop)
+	})
+}
+
+func (timer *Timer) stopped() bool {
+	select {
+	case <-timer.stop:
+		return true
+	default:
+		return false
+	}
+}
+
+// After runs handler once after the given duration, e.g. to reveal cards after 5 seconds.
+// The game must be added to the hub, otherwise a stopped timer is returned.
+func (hub *Hub[S]) After(game *Game[S], duration time.Duration, handler TimerHandler[S]) *Timer {
+	timer := &Timer{stop: make(chan struct{})}
+
+	if !hub.schedulable(game, timer) {
+		return timer
+	}
+
+	go func() {
+		t := time.NewTimer(duration)
+		defer t.Stop()
+
+		select {
+		case <-game.ctx.Done():
+		case <-timer.stop:
+		case <-t.C:
+			hub.fire(game, timer, handler)
+			timer.Stop()
+		}
+	}()
+
+	return timer
+}
+
+// Every runs handler repeatedly with the given interval until the timer is stopped,
+// e.g. for countdowns and turn timers. A stopped timer is returned when the interval
+// is not positive or the game is not added to the hub.
+func (hub *Hub[S]) Every(game *Game[S], interval time.Duration, handler TimerHandler[S]) *Timer {
+	timer := &Timer{stop: make(chan struct{})}
+
+	if interval <= 0 {
+		logx.Logger.Error(
+			"non-positive interval",
+			zap.String("desc", "could not schedule timer"),
+			zap.String("gameId", game.Id),
+			zap.Duration("interval", interval),
+		)
+
+		timer.Stop()
+
+		return timer
+	}
+
+	if !hub.schedulable(game, timer) {
+		return timer
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-game.ctx.Done():
+				return
+			case <-timer.stop:
+				return
+			case <-ticker.C:
+				hub.fire(game, timer, handler)
+			}
+		}
+	}()
+
+	return timer
+}
+
+// schedulable stops the timer of a game which has no context, i.e. it is not added by AddGame
+func (hub *Hub[S]) schedulable(game *Game[S], timer *Timer) bool {
+	if game.ctx != nil {
+		return true
+	}
+
+	logx.Logger.Error(
+		"game is not added to the hub",
+		zap.String("desc", "could not schedule timer"),
+		zap.String("gameId", game.Id),
+	)
+
+	timer.Stop()
+
+	return false
+}
+
+// fire executes the handler unless the timer was stopped while waiting for the game's goroutine
+func (hub *Hub[S]) fire(game *Game[S], timer *Timer, handler TimerHandler[S]) {
+	err := hub.invoke(game, nil, "timer", func() error {
+		if timer.stopped() {
+			return nil
+		}
+
+		return handler(hub, game)
+	})
+
+	if err != nil && !errors.Is(err, GameStopped) {
+		logx.Logger.Error(
+			err.Error(),
+			zap.String("desc", "could not execute timer handler"),
+			zap.String("gameId", game.Id),
+		)
+	}
+}
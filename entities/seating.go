@@ -0,0 +1,176 @@
+package entities
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+)
+
+var InvalidSeating = errors.New("seating strategy produced invalid seats")
+
+// SeatingStrategy sets Player.Index and Player.TeamId of the players of a new game.
+// Players are given in lobby order, humans first and then bots. Seats start
+// from 1 and must be unique, TeamId zero means the game has no teams.
+// random is the source of randomness of the game, strategies must not use another one.
+type SeatingStrategy interface {
+	Seat(players []*Player, random *rand.Rand) error
+}
+
+// RandomSeating shuffles every player, it is the default strategy
+type RandomSeating struct{}
+
+func (RandomSeating) Seat(players []*Player, random *rand.Rand) error {
+	for i, index := range random.Perm(len(players)) {
+		players[i].Index = index + 1
+	}
+
+	return nil
+}
+
+// LobbyOrderSeating keeps the order of the lobby
+type LobbyOrderSeating struct{}
+
+func (LobbyOrderSeating) Seat(players []*Player, _ *rand.Rand) error {
+	for i, player := range players {
+		player.Index = i + 1
+	}
+
+	return nil
+}
+
+// TeamBalancedSeating splits players into Teams teams of equal size, humans and bots
+// are spread separately so no team ends up with all the bots. Seats alternate between
+// teams, e.g. with two teams odd seats are team 1 and even seats are team 2.
+type TeamBalancedSeating struct {
+	Teams int
+}
+
+func (strategy TeamBalancedSeating) Seat(players []*Player, random *rand.Rand) error {
+	if strategy.Teams <= 0 {
+		return fmt.Errorf("%w: teams must be positive", InvalidSeating)
+	}
+
+	var humans, bots []*Player
+
+	for _, player := range players {
+		if player.IsBot {
+			bots = append(bots, player)
+		} else {
+			humans = append(humans, player)
+		}
+	}
+
+	random.Shuffle(len(humans), func(i, j int) { humans[i], humans[j] = humans[j], humans[i] })
+	random.Shuffle(len(bots), func(i, j int) { bots[i], bots[j] = bots[j], bots[i] })
+
+	for i, player := range append(humans, bots...) {
+		player.Index = i + 1
+		player.TeamId = i%strategy.Teams + 1
+	}
+
+	return nil
+}
+
+// SkillSnakeSeating drafts players into Teams teams by descending Skill in a snake
+// order (1, 2, 2, 1, 1, 2...) so the sum of skills of teams stays close. The seat
+// is the pick order, players of equal skill keep the lobby order.
+type SkillSnakeSeating struct {
+	Teams int
+	Skill func(player *Player) float64
+}
+
+func (strategy SkillSnakeSeating) Seat(players []*Player, _ *rand.Rand) error {
+	if strategy.Teams <= 0 || strategy.Skill == nil {
+		return fmt.Errorf("%w: teams must be positive and skill must be set", InvalidSeating)
+	}
+
+	picks := make([]*Player, len(players))
+	copy(picks, players)
+
+	sort.SliceStable(picks, func(i, j int) bool {
+		return strategy.Skill(picks[i]) > strategy.Skill(picks[j])
+	})
+
+	for i, player := range picks {
+		round, pick := i/strategy.Teams, i%strategy.Teams
+
+		if round%2 == 1 {
+			pick = strategy.Teams - 1 - pick
+		}
+
+		player.Index = i + 1
+		player.TeamId = pick + 1
+	}
+
+	return nil
+}
+
+// FixedSeating assigns the seats of Seats by player id, e.g. for tournaments.
+// Players missing from Seats take the free seats in lobby order.
+type FixedSeating struct {
+	Seats map[string]FixedSeat
+}
+
+type FixedSeat struct {
+	Index  int
+	TeamId int
+}
+
+func (strategy FixedSeating) Seat(players []*Player, _ *rand.Rand) error {
+	taken := map[int]bool{}
+
+	var unseated []*Player
+
+	for _, player := range players {
+		seat, fixed := strategy.Seats[player.Id]
+
+		if !fixed {
+			unseated = append(unseated, player)
+			continue
+		}
+
+		player.Index = seat.Index
+		player.TeamId = seat.TeamId
+		taken[seat.Index] = true
+	}
+
+	index := 1
+
+	for _, player := range unseated {
+		for taken[index] {
+			index++
+		}
+
+		player.Index = index
+		taken[index] = true
+	}
+
+	return nil
+}
+
+// SeatPlayers seats the players of a new game with the hub's SeatingStrategy
+// and makes sure every player has a unique seat
+func (hub *Hub[S]) SeatPlayers(players []*Player, random *rand.Rand) error {
+	err := hub.SeatingStrategy.Seat(players, random)
+
+	if err != nil {
+		return err
+	}
+
+	seats := make(map[int]string, len(players))
+
+	for _, player := range players {
+		if player.Index <= 0 {
+			return fmt.Errorf("%w: player %s has seat %d", InvalidSeating, player.Id, player.Index)
+		}
+
+		if other, taken := seats[player.Index]; taken {
+			return fmt.Errorf("%w: players %s and %s share seat %d", InvalidSeating, other, player.Id, player.Index)
+		}
+
+		seats[player.Index] = player.Id
+	}
+
+	return nil
+}
@@ -0,0 +1,202 @@
+package entities
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/AmirRezaM75/kenopsiarelay/pkg/randx"
+)
+
+// lobby returns the players of a lobby in order, humans first and then bots
+func lobby(humans, bots int) []*Player {
+	players := make([]*Player, 0, humans+bots)
+
+	for i := 0; i < humans; i++ {
+		players = append(players, &Player{Id: "human" + strconv.Itoa(i)})
+	}
+
+	for i := 0; i < bots; i++ {
+		players = append(players, &Player{Id: "bot" + strconv.Itoa(i), IsBot: true})
+	}
+
+	return players
+}
+
+func seat(t *testing.T, strategy SeatingStrategy, players []*Player, seed int64) {
+	t.Helper()
+
+	hub := &Hub[int]{SeatingStrategy: strategy}
+
+	if err := hub.SeatPlayers(players, randx.New(seed)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRandomSeatingIsDeterministic(t *testing.T) {
+	for seed := int64(1); seed <= 5; seed++ {
+		first, second := lobby(4, 2), lobby(4, 2)
+
+		seat(t, RandomSeating{}, first, seed)
+		seat(t, RandomSeating{}, second, seed)
+
+		for i := range first {
+			if first[i].Index != second[i].Index {
+				t.Fatalf("seed %d seats %s at %d and then at %d", seed, first[i].Id, first[i].Index, second[i].Index)
+			}
+		}
+	}
+}
+
+func TestSkillSnakeSeating(t *testing.T) {
+	tests := []struct {
+		name   string
+		teams  int
+		skills []float64
+		// teams of the players in lobby order
+		want []int
+	}{
+		{"two teams", 2, []float64{6, 5, 4, 3, 2, 1}, []int{1, 2, 2, 1, 1, 2}},
+		{"three teams", 3, []float64{6, 5, 4, 3, 2, 1}, []int{1, 2, 3, 3, 2, 1}},
+		{"reversed lobby", 2, []float64{1, 2, 3, 4}, []int{1, 2, 2, 1}},
+		{"equal skills keep lobby order", 2, []float64{1, 1, 1, 1}, []int{1, 2, 2, 1}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			players := lobby(len(test.skills), 0)
+			skills := map[*Player]float64{}
+
+			for i, player := range players {
+				skills[player] = test.skills[i]
+			}
+
+			strategy := SkillSnakeSeating{
+				Teams: test.teams,
+				Skill: func(player *Player) float64 { return skills[player] },
+			}
+
+			seat(t, strategy, players, 1)
+
+			for i, player := range players {
+				if player.TeamId != test.want[i] {
+					t.Fatalf("%s with skill %v is in team %d, want %d", player.Id, test.skills[i], player.TeamId, test.want[i])
+				}
+
+				// The seat is the pick order
+				for _, other := range players {
+					if skills[player] > skills[other] && player.Index > other.Index {
+						t.Fatalf("%s is seated after %s with a lower skill", player.Id, other.Id)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestTeamBalancedSeatingSpreadsBots(t *testing.T) {
+	tests := []struct {
+		name         string
+		humans, bots int
+		teams        int
+		seeds        int64
+	}{
+		{"even", 4, 4, 2, 20},
+		{"odd humans", 3, 3, 2, 20},
+		{"three teams", 5, 4, 3, 20},
+		{"only bots", 0, 6, 3, 5},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for seed := int64(1); seed <= test.seeds; seed++ {
+				players := lobby(test.humans, test.bots)
+
+				seat(t, TeamBalancedSeating{Teams: test.teams}, players, seed)
+
+				sizes := make([]int, test.teams+1)
+				bots := make([]int, test.teams+1)
+
+				for _, player := range players {
+					if player.TeamId < 1 || player.TeamId > test.teams {
+						t.Fatalf("seed %d puts %s in team %d", seed, player.Id, player.TeamId)
+					}
+
+					if want := (player.Index-1)%test.teams + 1; player.TeamId != want {
+						t.Fatalf("seed %d puts seat %d in team %d, want %d", seed, player.Index, player.TeamId, want)
+					}
+
+					sizes[player.TeamId]++
+
+					if player.IsBot {
+						bots[player.TeamId]++
+					}
+				}
+
+				for team := 2; team <= test.teams; team++ {
+					if diff := sizes[team] - sizes[1]; diff < -1 || diff > 1 {
+						t.Fatalf("seed %d gives teams the sizes %v", seed, sizes[1:])
+					}
+
+					if diff := bots[team] - bots[1]; diff < -1 || diff > 1 {
+						t.Fatalf("seed %d gives teams the bots %v", seed, bots[1:])
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestFixedSeatingFillsGaps(t *testing.T) {
+	players := lobby(5, 0)
+
+	strategy := FixedSeating{Seats: map[string]FixedSeat{
+		"human0": {Index: 2, TeamId: 1},
+		"human2": {Index: 4, TeamId: 2},
+	}}
+
+	seat(t, strategy, players, 1)
+
+	want := map[string]FixedSeat{
+		"human0": {Index: 2, TeamId: 1},
+		"human1": {Index: 1},
+		"human2": {Index: 4, TeamId: 2},
+		"human3": {Index: 3},
+		"human4": {Index: 5},
+	}
+
+	for _, player := range players {
+		got := FixedSeat{Index: player.Index, TeamId: player.TeamId}
+
+		if got != want[player.Id] {
+			t.Fatalf("%s is seated at %+v, want %+v", player.Id, got, want[player.Id])
+		}
+	}
+}
+
+func TestSeatPlayersRejectsInvalidSeats(t *testing.T) {
+	tests := []struct {
+		name     string
+		strategy SeatingStrategy
+	}{
+		{"shared seat", FixedSeating{Seats: map[string]FixedSeat{
+			"human0": {Index: 1},
+			"human1": {Index: 1},
+		}}},
+		{"zero seat", FixedSeating{Seats: map[string]FixedSeat{"human0": {Index: 0}}}},
+		{"no teams", TeamBalancedSeating{}},
+		{"no skill", SkillSnakeSeating{Teams: 2}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			hub := &Hub[int]{SeatingStrategy: test.strategy}
+
+			err := hub.SeatPlayers(lobby(3, 0), randx.New(1))
+
+			if !errors.Is(err, InvalidSeating) {
+				t.Fatalf("got %v, want %v", err, InvalidSeating)
+			}
+		})
+	}
+}
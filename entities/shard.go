@@ -0,0 +1,70 @@
+package entities
+
+import (
+	"sync"
+
+	"github.com/AmirRezaM75/kenopsiarelay/schemas"
+)
+
+// shard is the queue of the games dispatched by one goroutine. Run appends to it
+// without ever blocking, so a busy game can not hold back the other shards.
+type shard struct {
+	mutex   sync.Mutex
+	pending []*schemas.DispatcherMessage
+	// ready is signalled when a message is pushed or the shard is closed
+	ready  chan struct{}
+	closed bool
+}
+
+func newShard() *shard {
+	return &shard{ready: make(chan struct{}, 1)}
+}
+
+// push appends the message, a nil message is a barrier, see Hub.Run
+func (shard *shard) push(message *schemas.DispatcherMessage) {
+	shard.mutex.Lock()
+	shard.pending = append(shard.pending, message)
+	shard.mutex.Unlock()
+
+	shard.wake()
+}
+
+// pop takes the oldest message. When the shard is empty, ok is false
+// and closed tells whether the dispatch goroutine has to stop.
+func (shard *shard) pop() (message *schemas.DispatcherMessage, ok, closed bool) {
+	shard.mutex.Lock()
+	defer shard.mutex.Unlock()
+
+	if len(shard.pending) == 0 {
+		return nil, false, shard.closed
+	}
+
+	message = shard.pending[0]
+	shard.pending[0] = nil
+	shard.pending = shard.pending[1:]
+
+	return message, true, false
+}
+
+// close stops the dispatch goroutine once the pending messages are delivered
+func (shard *shard) close() {
+	shard.mutex.Lock()
+	shard.closed = true
+	shard.mutex.Unlock()
+
+	shard.wake()
+}
+
+func (shard *shard) len() int {
+	shard.mutex.Lock()
+	defer shard.mutex.Unlock()
+
+	return len(shard.pending)
+}
+
+func (shard *shard) wake() {
+	select {
+	case shard.ready <- struct{}{}:
+	default:
+	}
+}
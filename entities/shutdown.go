@@ -0,0 +1,150 @@
+package entities
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/AmirRezaM75/kenopsiarelay/pkg/logx"
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+)
+
+// ShutdownCloseReason is sent to every client with websocket.CloseGoingAway (1001)
+// so clients know they can reconnect to another node instead of showing an error
+const ShutdownCloseReason = "server restarting, retry"
+
+const defaultShutdownTimeout = 10 * time.Second
+
+var HubShuttingDown = errors.New("hub is shutting down")
+
+// Serve starts the Write goroutine of the player's connection and returns its blocking Read loop.
+// Both goroutines are tracked, so Shutdown can wait until they exit.
+// New connections are refused with HubShuttingDown once the shutdown has started.
+func (hub *Hub[S]) Serve(player *Player, connection *Connection) (func(), error) {
+	hub.connectionsMutex.Lock()
+
+	if hub.Draining() {
+		hub.connectionsMutex.Unlock()
+		return nil, HubShuttingDown
+	}
+
+	hub.connections.Add(2)
+	hub.connectionsMutex.Unlock()
+
+	go func() {
+		defer hub.connections.Done()
+		player.Write(connection)
+	}()
+
+	return func() {
+		defer hub.connections.Done()
+		Read(player, connection, hub)
+	}, nil
+}
+
+// Draining reports whether the shutdown has started and new messages are not accepted
+func (hub *Hub[S]) Draining() bool {
+	select {
+	case <-hub.quit:
+		return true
+	default:
+		return false
+	}
+}
+
+// Shutdown gracefully stops the hub:
+//  1. new connections and inbound messages are refused
+//  2. messages queued in Dispatch and the shards are delivered to players' outbound queues
+//  3. each player flushes its queue and receives a close frame with code 1001
+//  4. it waits until every Read and Write goroutine has exited, messages sent by
+//     the hooks they fire in the meantime are still dispatched
+//
+// When ctx expires before that, the remaining players are kicked and ctx.Err() is returned.
+// Shutdown is called by Run when Hub.Context is cancelled, calling it more than once
+// waits for the first call and returns the same result.
+func (hub *Hub[S]) Shutdown(ctx context.Context) error {
+	hub.shutdownOnce.Do(func() {
+		hub.shutdownErr = hub.drain(ctx)
+	})
+
+	return hub.shutdownErr
+}
+
+func (hub *Hub[S]) drain(ctx context.Context) error {
+	// Stops games' goroutines, tick loops, timers and the sweeper
+	defer hub.cancel()
+	// Stops dispatching, nothing is sent to the players anymore
+	defer close(hub.stopped)
+
+	hub.connectionsMutex.Lock()
+	close(hub.quit)
+	hub.connectionsMutex.Unlock()
+
+	select {
+	case <-hub.dispatched:
+	case <-ctx.Done():
+		hub.kickAll()
+		return ctx.Err()
+	}
+
+	hub.Games.Range(func(gameId string, game *Game[S]) bool {
+		game.Players.Range(func(playerId string, player *Player) bool {
+			player.Close(websocket.CloseGoingAway, ShutdownCloseReason)
+			return true
+		})
+		game.Spectators.Range(func(spectatorId string, spectator *Player) bool {
+			spectator.Close(websocket.CloseGoingAway, ShutdownCloseReason)
+			return true
+		})
+		return true
+	})
+
+	done := make(chan struct{})
+
+	go func() {
+		hub.connections.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		hub.kickAll()
+		return ctx.Err()
+	}
+}
+
+// shutdownOnCancel starts the graceful shutdown when Hub.Context is cancelled
+func (hub *Hub[S]) shutdownOnCancel() {
+	select {
+	case <-hub.quit:
+	case <-hub.Context.Done():
+		ctx, cancel := context.WithTimeout(context.Background(), hub.ShutdownTimeout)
+		defer cancel()
+
+		err := hub.Shutdown(ctx)
+
+		if err != nil {
+			logx.Logger.Warn(
+				err.Error(),
+				zap.String("desc", "could not shut down the hub gracefully"),
+			)
+		}
+	}
+}
+
+func (hub *Hub[S]) kickAll() {
+	hub.Games.Range(func(gameId string, game *Game[S]) bool {
+		game.Players.Range(func(playerId string, player *Player) bool {
+			player.Kick()
+			return true
+		})
+		game.Spectators.Range(func(spectatorId string, spectator *Player) bool {
+			spectator.Kick()
+			return true
+		})
+		return true
+	})
+}
@@ -0,0 +1,133 @@
+package entities
+
+import (
+	"errors"
+
+	"github.com/AmirRezaM75/kenopsiarelay/pkg/logx"
+	"github.com/AmirRezaM75/kenopsiarelay/schemas"
+	"go.uber.org/zap"
+)
+
+var (
+	SpectatingDisabled = errors.New("spectating is disabled")
+	SpectatorsFull     = errors.New("game has reached the maximum number of spectators")
+	SpectatorIsPlayer  = errors.New("players can not spectate their own game")
+)
+
+// SpectatorInput is replied to every message a spectator sends
+var SpectatorInput = NewClientError("spectator_input", "spectators can not send messages")
+
+// SpectatorAuthorizer decides whether the user may watch the game, returning an error refuses it.
+// It runs on the joining goroutine, not on the game's goroutine, so it may call other services
+// but must not touch Game.State.
+type SpectatorAuthorizer[S GameState] func(hub *Hub[S], game *Game[S], userId string) error
+
+// SpectatorJoinedHandler is fired on each connection of a spectator, e.g. to send a snapshot
+type SpectatorJoinedHandler[S GameState] func(hub *Hub[S], game *Game[S], spectator *Player) error
+
+// SpectatorLeftHandler is fired when the last connection of a spectator is closed
+type SpectatorLeftHandler[S GameState] func(hub *Hub[S], game *Game[S], spectator *Player) error
+
+// AddSpectator authorizes the user and attaches the connection to its spectator,
+// which is created on the first connection. Spectating is disabled without
+// AuthorizeSpectator and MaxSpectators caps the spectators of each game.
+func (hub *Hub[S]) AddSpectator(game *Game[S], userId string, connection *Connection) (*Player, error) {
+	if hub.AuthorizeSpectator == nil {
+		return nil, SpectatingDisabled
+	}
+
+	if _, isPlayer := game.Players.Load(userId); isPlayer {
+		return nil, SpectatorIsPlayer
+	}
+
+	err := hub.AuthorizeSpectator(hub, game, userId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	game.spectatorsMutex.Lock()
+	defer game.spectatorsMutex.Unlock()
+
+	spectator, exists := game.Spectators.Load(userId)
+
+	if !exists {
+		if hub.MaxSpectators > 0 && game.Spectators.Len() >= hub.MaxSpectators {
+			return nil, SpectatorsFull
+		}
+
+		spectator = &Player{Id: userId, GameId: game.Id, IsSpectator: true}
+	}
+
+	// DuplicateConnectionPolicy applies to spectators as well
+	_, err = spectator.Reconnect(connection)
+
+	if err != nil {
+		return nil, err
+	}
+
+	game.Spectators.Store(userId, spectator)
+
+	return spectator, nil
+}
+
+// removeSpectator removes the spectator once its last connection is closed
+// and fires OnSpectatorLeft. A spectator connected again in the meantime is kept.
+func (hub *Hub[S]) removeSpectator(game *Game[S], spectator *Player) {
+	game.spectatorsMutex.Lock()
+
+	removed := spectator.ConnectionCount() == 0
+
+	if removed {
+		game.Spectators.Delete(spectator.Id)
+	}
+
+	game.spectatorsMutex.Unlock()
+
+	if !removed || game.ctx.Err() != nil {
+		return
+	}
+
+	err := hub.HandleSpectatorLeft(game, spectator)
+
+	if err != nil {
+		logx.Logger.Error(
+			err.Error(),
+			zap.String("desc", "could not execute handler when spectator is left"),
+			zap.String("gameId", game.Id),
+			zap.String("playerId", spectator.Id),
+		)
+	}
+}
+
+// deliverSpectators delivers a SpectatorVisible message to the spectators using its codec
+func (hub *Hub[S]) deliverSpectators(game *Game[S], message *schemas.DispatcherMessage) {
+	game.Spectators.Range(func(spectatorId string, spectator *Player) bool {
+		if message.Codec == "" || hub.CodecOf(spectator).Name() == message.Codec {
+			hub.deliver(spectator, message)
+		}
+		return true
+	})
+}
+
+// HandleSpectatorJoined executes OnSpectatorJoined with the hub's concurrency guarantees
+func (hub *Hub[S]) HandleSpectatorJoined(game *Game[S], spectator *Player) error {
+	if hub.OnSpectatorJoined == nil {
+		return nil
+	}
+
+	return hub.invoke(game, spectator, "spectator_joined", func() error {
+		return hub.OnSpectatorJoined(hub, game, spectator)
+	})
+}
+
+// HandleSpectatorLeft executes OnSpectatorLeft with the hub's concurrency guarantees
+func (hub *Hub[S]) HandleSpectatorLeft(game *Game[S], spectator *Player) error {
+	if hub.OnSpectatorLeft == nil {
+		return nil
+	}
+
+	return hub.invoke(game, spectator, "spectator_left", func() error {
+		return hub.OnSpectatorLeft(hub, game, spectator)
+	})
+}
@@ -0,0 +1,171 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/AmirRezaM75/kenopsiarelay/pkg/logx"
+	"github.com/AmirRezaM75/kenopsiarelay/schemas"
+	"go.uber.org/zap"
+)
+
+// BotTakeoverHandler is fired when a bot starts playing for a disconnected player
+type BotTakeoverHandler[S GameState] func(hub *Hub[S], game *Game[S], player *Player) error
+
+// BotHandbackHandler is fired when the player is back and the bot has stopped.
+// It may run before or after OnPlayerReconnected of the same reconnect.
+type BotHandbackHandler[S GameState] func(hub *Hub[S], game *Game[S], player *Player) error
+
+// ControlledByBot reports whether a bot is playing for the player, see Hub.BotTakeoverAfter
+func (player *Player) ControlledByBot() bool {
+	player.mutex.Lock()
+	defer player.mutex.Unlock()
+
+	return player.bot != nil
+}
+
+// scheduleTakeover lets a bot take the seat of the player if it is still
+// disconnected after BotTakeoverAfter. A player who has already left when
+// ReconnectGracePeriod is not longer than BotTakeoverAfter is not taken over.
+func (hub *Hub[S]) scheduleTakeover(game *Game[S], player *Player, generation uint64) {
+	if hub.BotTakeoverAfter <= 0 || hub.BotFactory == nil || player.IsBot {
+		return
+	}
+
+	time.AfterFunc(hub.BotTakeoverAfter, func() {
+		hub.takeover(game, player, generation)
+	})
+}
+
+func (hub *Hub[S]) takeover(game *Game[S], player *Player, generation uint64) {
+	if game.ctx.Err() != nil || !game.isSeated(player) {
+		return
+	}
+
+	bot := hub.BotFactory(game, player)
+
+	if bot == nil {
+		return
+	}
+
+	connection := NewConnection(nil, ConnectionOptions{
+		QueueSize: hub.PlayerQueueSize,
+		Codec:     hub.Codecs[0],
+	})
+
+	player.mutex.Lock()
+
+	// The player has reconnected or left in the meantime
+	if player.generation != generation || !player.away {
+		player.mutex.Unlock()
+		return
+	}
+
+	// The seat is taken, so the player does not leave when the grace period is over
+	if player.graceTimer != nil {
+		player.graceTimer.Stop()
+		player.graceTimer = nil
+	}
+
+	player.away = false
+	player.bot = connection
+	player.connections = append(player.connections, connection)
+	// Messages to the player are encoded for the bot until the human is back, see Hub.CodecOf
+	player.codec = connection.options.Codec
+	player.updateConnected()
+
+	player.mutex.Unlock()
+
+	logx.Logger.Info(
+		"bot takes over disconnected player",
+		zap.String("gameId", game.Id),
+		zap.String("playerId", player.Id),
+		zap.Int("seat", player.Index),
+	)
+
+	hub.publishBotSeat(game, player, schemas.BotTakeoverEvent)
+
+	if hub.OnBotTakeover != nil {
+		err := hub.invoke(game, player, "bot_takeover", func() error {
+			return hub.OnBotTakeover(hub, game, player)
+		})
+
+		if err != nil {
+			logx.Logger.Error(
+				err.Error(),
+				zap.String("desc", "could not execute handler when bot takes over"),
+				zap.String("gameId", game.Id),
+				zap.String("playerId", player.Id),
+			)
+		}
+	}
+
+	ctx := &BotContext[S]{Hub: hub, Game: game, Player: player, Codec: connection.options.Codec}
+
+	go hub.runBot(ctx, bot, connection, true)
+}
+
+// handback is called by the bot's goroutine after Reconnect has released the seat
+func (hub *Hub[S]) handback(game *Game[S], player *Player) {
+	logx.Logger.Info(
+		"player is back, bot hands over",
+		zap.String("gameId", game.Id),
+		zap.String("playerId", player.Id),
+		zap.Int("seat", player.Index),
+	)
+
+	hub.publishBotSeat(game, player, schemas.BotHandbackEvent)
+
+	if hub.OnBotHandback == nil {
+		return
+	}
+
+	err := hub.invoke(game, player, "bot_handback", func() error {
+		return hub.OnBotHandback(hub, game, player)
+	})
+
+	if err != nil {
+		logx.Logger.Error(
+			err.Error(),
+			zap.String("desc", "could not execute handler when bot hands back"),
+			zap.String("gameId", game.Id),
+			zap.String("playerId", player.Id),
+		)
+	}
+}
+
+// releaseBot frees the seat of a stopped takeover bot. It reports whether
+// Reconnect stopped the bot because the player is back.
+func (player *Player) releaseBot(connection *Connection) (handedBack bool) {
+	player.mutex.Lock()
+	defer player.mutex.Unlock()
+
+	if player.bot == connection {
+		player.bot = nil
+	}
+
+	return connection.handedBack
+}
+
+func (hub *Hub[S]) publishBotSeat(game *Game[S], player *Player, event func(gameId, lobbyId, gameSlug, playerId string, seat int) (string, error)) {
+	if hub.PublisherService == nil {
+		return
+	}
+
+	message, err := event(game.Id, game.LobbyId, hub.GameSlug, player.Id, player.Index)
+	if err != nil {
+		logx.Logger.Error("failed to create bot seat event",
+			zap.String("gameId", game.Id),
+			zap.Error(err),
+		)
+
+		return
+	}
+
+	err = hub.PublisherService.Publish(message)
+	if err != nil {
+		logx.Logger.Error("failed to publish bot seat event",
+			zap.String("gameId", game.Id),
+			zap.Error(err),
+		)
+	}
+}
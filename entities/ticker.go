@@ -0,0 +1,77 @@
+package entities
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/AmirRezaM75/kenopsiarelay/pkg/logx"
+	"go.uber.org/zap"
+)
+
+// TickHandler is called TickRate times per second for every running game.
+// tick starts from 1 and dt is the actual time elapsed since the previous tick,
+// which may be longer than the budget when the previous tick has overrun.
+type TickHandler[S GameState] func(hub *Hub[S], game *Game[S], tick uint64, dt time.Duration) error
+
+// startTicking runs the fixed-timestep loop of the game in its own goroutine.
+// The loop is started when the game becomes active and stops when ctx is done,
+// i.e. the game is paused, finished, removed or the hub's context is cancelled.
+func (hub *Hub[S]) startTicking(ctx context.Context, game *Game[S]) {
+	if hub.OnTick == nil || hub.TickRate <= 0 {
+		return
+	}
+
+	go hub.tick(ctx, game)
+}
+
+func (hub *Hub[S]) tick(ctx context.Context, game *Game[S]) {
+	budget := time.Second / time.Duration(hub.TickRate)
+
+	ticker := time.NewTicker(budget)
+	defer ticker.Stop()
+
+	var tick uint64
+
+	last := time.Now()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			tick++
+
+			dt := now.Sub(last)
+			last = now
+
+			err := hub.invoke(game, nil, "tick", func() error {
+				return hub.OnTick(hub, game, tick, dt)
+			})
+
+			if err != nil && !errors.Is(err, GameStopped) {
+				logx.Logger.Error(
+					err.Error(),
+					zap.String("desc", "could not execute handler on tick"),
+					zap.String("gameId", game.Id),
+					zap.Uint64("tick", tick),
+				)
+			}
+
+			// time.Ticker drops ticks for slow receivers, so an overrun
+			// shows up as a longer dt in the next tick instead of a burst.
+			if elapsed := time.Since(now); elapsed > budget {
+				game.TickOverruns.Add(1)
+				hub.Metrics.TickOverruns.Inc(hub.GameSlug)
+
+				logx.Logger.Warn(
+					"tick overrun",
+					zap.String("gameId", game.Id),
+					zap.Uint64("tick", tick),
+					zap.Duration("elapsed", elapsed),
+					zap.Duration("budget", budget),
+				)
+			}
+		}
+	}
+}
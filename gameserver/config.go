@@ -2,7 +2,9 @@ package gameserver
 
 import (
 	"context"
+	"time"
 
+	"github.com/AmirRezaM75/kenopsiarelay/codecs"
 	"github.com/AmirRezaM75/kenopsiarelay/entities"
 )
 
@@ -18,29 +20,176 @@ type Config[S entities.GameState] struct {
 	// Higher values handle traffic spikes better but use more memory
 	DispatchBufferSize int
 
+	// PARALLELISM: Number of dispatch goroutines
+	// Messages are routed by game id, so each game keeps its ordering
+	// while a busy game does not delay the others
+	DispatchShards int
+
 	// GameSlug which is defined in GameData service
 	GameSlug          string
 	UserService       UserServiceConfig
 	LobbyService      LobbyServiceConfig
 	Publisher         PublisherConfig
 	Router            RouterConfig
+	Metrics           MetricsConfig
+	Resume            ResumeConfig
 	OnMessageReceived entities.MessageReceivedHandler[S]
 	OnPlayerJoined    entities.PlayerJoinedHandler[S]
 	OnPlayerLeft      entities.PlayerLeftHandler[S]
 	OnGameCreated     entities.GameCreatedHandler[S]
 	GameStateFactory  func() S
+
+	// SLOW CONSUMERS: Each player has a bounded outbound queue of PlayerQueueSize messages
+	// SlowConsumerPolicy decides whether to drop, coalesce or kick when it is full
+	PlayerQueueSize         int
+	SlowConsumerPolicy      entities.SlowConsumerPolicy
+	SlowConsumerCloseReason string
+
+	// CONCURRENCY: Opt-in actor mode, every handler of a game runs on the game's own goroutine
+	// so game handlers can mutate the state as plain single-threaded code
+	SerializeGames bool
+	GameInboxSize  int
+
+	// REAL-TIME GAMES: OnTick is called TickRate times per second for each game
+	// The loop is owned by the framework and stopped when the game is removed
+	OnTick   entities.TickHandler[S]
+	TickRate int
+
+	// LIFECYCLE HOOK: Fired on each game status transition (pending → active ⇄ paused → finished → removed)
+	OnGameStatusChanged entities.GameStatusChangedHandler[S]
+
+	// MEMORY MANAGEMENT: Games are ended and removed automatically by these policies
+	// Without them games stay in memory until RemoveGame is called
+	ExpiryPolicy  entities.ExpiryPolicy
+	OnGameExpired entities.GameExpiredHandler[S]
+
+	// GRACEFUL SHUTDOWN: Deadline for flushing queued messages and closing connections
+	// after Context is cancelled, defaults to 10 seconds
+	ShutdownTimeout time.Duration
+
+	// ERROR REPLIES: Encodes the error sent back to a player whose message is rejected
+	// Handlers return *entities.ClientError to reject a message, defaults to JSON
+	ErrorEncoder entities.ErrorEncoder
+
+	// MIDDLEWARES: Wrap OnMessageReceived and the connection hooks of players, see MessageMiddleware
+	// e.g. entities.RecoverMiddleware[S](), entities.RateLimitMiddleware[S](10, 20)
+	Middlewares []entities.MessageMiddleware[S]
+
+	// PANIC ISOLATION: Panics of handlers are always recovered and logged
+	// The policy decides whether to ignore, kick the player or end the affected game
+	PanicPolicy entities.PanicPolicy
+
+	// CODECS: Clients pick one through Sec-WebSocket-Protocol, the first one is the default
+	// e.g. []codecs.Codec{codecs.JSON{}, codecs.MessagePack{}, codecs.Protobuf{}}
+	Codecs []codecs.Codec
+
+	// HEARTBEATS: Dead connections are detected when no pong arrives within
+	// PingInterval + PongTimeout, defaults are 30s, 10s and 10s for WriteTimeout
+	PingInterval time.Duration
+	PongTimeout  time.Duration
+	WriteTimeout time.Duration
+
+	// ABUSE PROTECTION: Inbound messages larger than MaxMessageSize close the connection with 1009
+	// InboundRateLimit is a per-player token bucket with a drop, warn or kick policy
+	MaxMessageSize   int64
+	InboundRateLimit entities.InboundRateLimit
+
+	// RECONNECTION: A refresh fires OnPlayerDisconnected, then OnPlayerReconnected if the player
+	// joins again within ReconnectGracePeriod, otherwise OnPlayerLeft once the period is over
+	ReconnectGracePeriod time.Duration
+	OnPlayerDisconnected entities.PlayerDisconnectedHandler[S]
+	OnPlayerReconnected  entities.PlayerReconnectedHandler[S]
+
+	// SECOND TAB: Replace the old connection with a "session taken over" close frame (default),
+	// reject the new one, or keep both and fan outbound messages out to each of them
+	DuplicateConnectionPolicy entities.DuplicateConnectionPolicy
+
+	// SPECTATORS: Users join through /games/{id}/spectate when AuthorizeSpectator allows it
+	// They only receive messages marked SpectatorVisible, MaxSpectators of zero means unlimited
+	AuthorizeSpectator entities.SpectatorAuthorizer[S]
+	MaxSpectators      int
+	OnSpectatorJoined  entities.SpectatorJoinedHandler[S]
+	OnSpectatorLeft    entities.SpectatorLeftHandler[S]
+
+	// ROSTER CHANGES: Fired by hub.AddPlayer for late joiners and substitutes and by hub.RemovePlayer
+	// Removed players receive a close frame with the reason, banned ones are refused by Join
+	OnPlayerAdded   entities.PlayerAddedHandler[S]
+	OnPlayerRemoved entities.PlayerRemovedHandler[S]
+
+	// BOTS: BotFactory drives the bots of the lobby on the server, they receive the same messages
+	// as humans and act through OnMessageReceived after BotThinkDelay plus a random BotThinkJitter
+	BotFactory     entities.BotFactory[S]
+	BotThinkDelay  time.Duration
+	BotThinkJitter time.Duration
+
+	// BOT TAKEOVER: Opt-in, a bot of BotFactory plays for a player disconnected longer than
+	// BotTakeoverAfter and hands the seat back when the player joins again.
+	// BotTakeoverAfter must be shorter than ReconnectGracePeriod, players who have
+	// already left are not taken over.
+	BotTakeoverAfter time.Duration
+	OnBotTakeover    entities.BotTakeoverHandler[S]
+	OnBotHandback    entities.BotHandbackHandler[S]
+
+	// SEATING: Sets Player.Index and Player.TeamId when a game is created, defaults to RandomSeating
+	// e.g. entities.LobbyOrderSeating{}, entities.TeamBalancedSeating{Teams: 2}, entities.FixedSeating{...}
+	SeatingStrategy entities.SeatingStrategy
+
+	// RANDOMNESS: Each game has its own random source, see Game.Rand. Its seed is published
+	// in GameCreatedEvent and defaults to the current time, entities.FixedSeed(42) forces it.
+	SeedFactory entities.SeedFactory
 }
 
 func (c *Config[S]) ToHubConfig() *entities.HubConfig[S] {
 	return &entities.HubConfig[S]{
 		Context:            c.Context,
 		DispatchBufferSize: c.DispatchBufferSize,
+		DispatchShards:     c.DispatchShards,
 		GameSlug:           c.GameSlug,
 		OnMessageReceived:  c.OnMessageReceived,
 		OnPlayerJoined:     c.OnPlayerJoined,
 		OnPlayerLeft:       c.OnPlayerLeft,
 		OnGameCreated:      c.OnGameCreated,
 		GameStateFactory:   c.GameStateFactory,
+
+		PlayerQueueSize:         c.PlayerQueueSize,
+		SlowConsumerPolicy:      c.SlowConsumerPolicy,
+		SlowConsumerCloseReason: c.SlowConsumerCloseReason,
+		SerializeGames:          c.SerializeGames,
+		GameInboxSize:           c.GameInboxSize,
+		OnTick:                  c.OnTick,
+		TickRate:                c.TickRate,
+		OnGameStatusChanged:     c.OnGameStatusChanged,
+		ExpiryPolicy:            c.ExpiryPolicy,
+		OnGameExpired:           c.OnGameExpired,
+		ShutdownTimeout:         c.ShutdownTimeout,
+		ErrorEncoder:            c.ErrorEncoder,
+		Middlewares:             c.Middlewares,
+		PanicPolicy:             c.PanicPolicy,
+		Codecs:                  c.Codecs,
+		PingInterval:            c.PingInterval,
+		PongTimeout:             c.PongTimeout,
+		WriteTimeout:            c.WriteTimeout,
+		MaxMessageSize:          c.MaxMessageSize,
+		InboundRateLimit:        c.InboundRateLimit,
+		ReconnectGracePeriod:    c.ReconnectGracePeriod,
+		OnPlayerDisconnected:    c.OnPlayerDisconnected,
+		OnPlayerReconnected:     c.OnPlayerReconnected,
+
+		DuplicateConnectionPolicy: c.DuplicateConnectionPolicy,
+		AuthorizeSpectator:        c.AuthorizeSpectator,
+		MaxSpectators:             c.MaxSpectators,
+		OnSpectatorJoined:         c.OnSpectatorJoined,
+		OnSpectatorLeft:           c.OnSpectatorLeft,
+		OnPlayerAdded:             c.OnPlayerAdded,
+		OnPlayerRemoved:           c.OnPlayerRemoved,
+		BotFactory:                c.BotFactory,
+		BotThinkDelay:             c.BotThinkDelay,
+		BotThinkJitter:            c.BotThinkJitter,
+		BotTakeoverAfter:          c.BotTakeoverAfter,
+		OnBotTakeover:             c.OnBotTakeover,
+		OnBotHandback:             c.OnBotHandback,
+		SeatingStrategy:           c.SeatingStrategy,
+		SeedFactory:               c.SeedFactory,
 	}
 }
 
@@ -72,3 +221,20 @@ type RedisConfig struct {
 type RouterConfig struct {
 	AllowedOrigins []string
 }
+
+// MetricsConfig contains configuration of the Prometheus metrics endpoint
+type MetricsConfig struct {
+	// Enabled registers the metrics route on the router
+	Enabled bool
+	// Path of the metrics route, defaults to /metrics
+	Path string
+}
+
+// ResumeConfig contains configuration of resume tokens, which let a client
+// reconnect with the resumeToken query parameter instead of a new ticket
+type ResumeConfig struct {
+	// Secret signs the tokens, resume tokens are disabled when it is empty
+	Secret string
+	// TTL of each token, defaults to 2 minutes
+	TTL time.Duration
+}
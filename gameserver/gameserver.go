@@ -1,9 +1,9 @@
 package gameserver
 
 import (
-	"math/rand"
-	"time"
+	"context"
 
+	"github.com/AmirRezaM75/kenopsiarelay/codecs"
 	"github.com/AmirRezaM75/kenopsiarelay/entities"
 	"github.com/AmirRezaM75/kenopsiarelay/handlers"
 	"github.com/AmirRezaM75/kenopsiarelay/pkg/logx"
@@ -30,10 +30,6 @@ type Middlewares struct {
 
 // NewGameServer creates a new game server with the provided configuration
 func NewGameServer[S entities.GameState](config Config[S]) *GameServer[S] {
-	// Previously rand.Seed() was called on every game creation which could
-	// lead to identical seeds for rapidly created games, reducing randomness
-	rand.Seed(time.Now().UnixNano())
-
 	logx.NewLogger()
 
 	publisherService := services.NewPublisherService(
@@ -56,7 +52,9 @@ func NewGameServer[S entities.GameState](config Config[S]) *GameServer[S] {
 		config.LobbyService.Token,
 	)
 
-	gameService := services.NewGameService(hub, userRepository, lobbyRepository, publisherService)
+	resumeTokenService := services.NewResumeTokenService(config.Resume.Secret, config.Resume.TTL)
+
+	gameService := services.NewGameService(hub, userRepository, lobbyRepository, publisherService, resumeTokenService)
 
 	router := chi.NewRouter()
 	router.Use(cors.Handler(cors.Options{
@@ -71,7 +69,17 @@ func NewGameServer[S entities.GameState](config Config[S]) *GameServer[S] {
 
 	serviceAdapter := &gameServiceAdapter[S]{gameService: gameService}
 
-	handlers.NewGameHandler(router, serviceAdapter, authMiddleware)
+	handlers.NewGameHandler(router, serviceAdapter, authMiddleware, codecs.Names(hub.Codecs))
+
+	if config.Metrics.Enabled {
+		path := config.Metrics.Path
+
+		if path == "" {
+			path = "/metrics"
+		}
+
+		router.Handle(path, hub.Metrics.Registry)
+	}
 
 	gameServer := &GameServer[S]{
 		router:      router,
@@ -98,16 +106,12 @@ func (gs *GameServer[S]) GetAuthMiddleware() middlewares.Authenticate {
 	return gs.middlewares.auth
 }
 
-// Shutdown provides explicit shutdown method for immediate cleanup
+// Shutdown gracefully drains the hub: queued messages are flushed, clients receive
+// a close frame with code 1001 and it waits for every connection goroutine to exit.
+// When ctx expires first, remaining players are kicked and ctx.Err() is returned.
 // Note: Hub will also shut down automatically when user cancels the context
-func (gs *GameServer[S]) Shutdown() {
-	gs.hub.Games.Range(func(gameId string, game *entities.Game[S]) bool {
-		game.Players.Range(func(playerId string, player *entities.Player) bool {
-			player.Kick()
-			return true
-		})
-		return true
-	})
+func (gs *GameServer[S]) Shutdown(ctx context.Context) error {
+	return gs.hub.Shutdown(ctx)
 }
 
 // gameServiceAdapter adapts the generic service to the expected interface
@@ -122,3 +126,11 @@ func (a *gameServiceAdapter[S]) Create(user kenopsiauser.User, payload schemas.C
 func (a *gameServiceAdapter[S]) Join(gameId, ticketId string, connection *websocket.Conn) (func(), error) {
 	return a.gameService.Join(gameId, ticketId, connection)
 }
+
+func (a *gameServiceAdapter[S]) Resume(gameId, resumeToken string, connection *websocket.Conn) (func(), error) {
+	return a.gameService.Resume(gameId, resumeToken, connection)
+}
+
+func (a *gameServiceAdapter[S]) Spectate(gameId, ticketId string, connection *websocket.Conn) (func(), error) {
+	return a.gameService.Spectate(gameId, ticketId, connection)
+}
@@ -29,20 +29,28 @@ var upgrader = websocket.Upgrader{
 type GameServiceInterface interface {
 	Create(user kenopsiauser.User, payload schemas.CreateGameRequest) (*schemas.CreateGameResponse, error)
 	Join(gameId, ticketId string, connection *websocket.Conn) (func(), error)
+	Resume(gameId, resumeToken string, connection *websocket.Conn) (func(), error)
+	Spectate(gameId, ticketId string, connection *websocket.Conn) (func(), error)
 }
 
 type GameHandler struct {
 	gameService GameServiceInterface
+	upgrader    websocket.Upgrader
 }
 
+// NewGameHandler registers game routes, subprotocols are the names of
+// the supported codecs offered to clients during the WebSocket upgrade
 func NewGameHandler(
 	router *chi.Mux,
 	gameService GameServiceInterface,
 	authMiddleware middlewares.Authenticate,
+	subprotocols []string,
 ) {
-	gameHandler := GameHandler{gameService: gameService}
+	gameHandler := GameHandler{gameService: gameService, upgrader: upgrader}
+	gameHandler.upgrader.Subprotocols = subprotocols
 	router.With(authMiddleware.Handle).Post("/games", gameHandler.create)
 	router.Get("/games/{id}/join", gameHandler.join)
+	router.Get("/games/{id}/spectate", gameHandler.spectate)
 }
 
 func (gameHandler GameHandler) create(w http.ResponseWriter, r *http.Request) {
@@ -82,7 +90,8 @@ func (gameHandler GameHandler) create(w http.ResponseWriter, r *http.Request) {
 }
 
 func (gameHandler GameHandler) join(w http.ResponseWriter, r *http.Request) {
-	connection, err := upgrader.Upgrade(w, r, nil)
+	// The codec is negotiated here through Sec-WebSocket-Protocol header
+	connection, err := gameHandler.upgrader.Upgrade(w, r, nil)
 
 	if err != nil {
 		logx.Logger.Error(
@@ -97,34 +106,78 @@ func (gameHandler GameHandler) join(w http.ResponseWriter, r *http.Request) {
 
 	ticketId := r.URL.Query().Get("ticketId")
 
-	if ticketId == "" {
+	// A resume token, received in the "resume" message of a previous join, replaces the ticket
+	resumeToken := r.URL.Query().Get("resumeToken")
+
+	if ticketId == "" && resumeToken == "" {
 		logx.Logger.Info("ticketId parameter is missing in join request")
 		w.WriteHeader(http.StatusUnprocessableEntity)
 		return
 	}
 
-	reader, err := gameHandler.gameService.Join(gameId, ticketId, connection)
+	var reader func()
+
+	if resumeToken != "" {
+		reader, err = gameHandler.gameService.Resume(gameId, resumeToken, connection)
+	} else {
+		reader, err = gameHandler.gameService.Join(gameId, ticketId, connection)
+	}
 
 	if err != nil {
-		// TODO: Add onJoinFailed handler to return decoded message
-		err = connection.WriteMessage(websocket.BinaryMessage, []byte(err.Error()))
-		if err != nil {
-			logx.Logger.Error(
-				err.Error(),
-				zap.String("desc", "could not write error message to websocket"),
-			)
-		}
+		refuse(connection, err)
+		return
+	}
 
-		err = connection.Close()
-		if err != nil {
-			logx.Logger.Error(
-				err.Error(),
-				zap.String("desc", "could not close websocket connection"),
-			)
-		}
+	reader()
+}
+
+// spectate upgrades the request and joins the game as a spectator
+func (gameHandler GameHandler) spectate(w http.ResponseWriter, r *http.Request) {
+	connection, err := gameHandler.upgrader.Upgrade(w, r, nil)
+
+	if err != nil {
+		logx.Logger.Error(
+			err.Error(),
+			zap.String("desc", "could not upgrade http request"),
+		)
+		w.WriteHeader(400)
+		return
+	}
+
+	ticketId := r.URL.Query().Get("ticketId")
 
+	if ticketId == "" {
+		logx.Logger.Info("ticketId parameter is missing in spectate request")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		return
+	}
+
+	reader, err := gameHandler.gameService.Spectate(r.PathValue("id"), ticketId, connection)
+
+	if err != nil {
+		refuse(connection, err)
 		return
 	}
 
 	reader()
 }
+
+// refuse writes the error to the upgraded connection and closes it
+func refuse(connection *websocket.Conn, err error) {
+	// TODO: Add onJoinFailed handler to return decoded message
+	err = connection.WriteMessage(websocket.BinaryMessage, []byte(err.Error()))
+	if err != nil {
+		logx.Logger.Error(
+			err.Error(),
+			zap.String("desc", "could not write error message to websocket"),
+		)
+	}
+
+	err = connection.Close()
+	if err != nil {
+		logx.Logger.Error(
+			err.Error(),
+			zap.String("desc", "could not close websocket connection"),
+		)
+	}
+}
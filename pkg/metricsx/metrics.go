@@ -0,0 +1,334 @@
+package metricsx
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Registry keeps metrics and writes them in Prometheus text exposition format.
+// It has no dependency on a Prometheus client, so it can be scraped locally.
+type Registry struct {
+	mutex      sync.Mutex
+	collectors []collector
+}
+
+type collector interface {
+	write(w *bufio.Writer)
+}
+
+// Labels are constant label pairs of a GaugeFunc
+type Labels map[string]string
+
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+func (registry *Registry) register(c collector) {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+
+	registry.collectors = append(registry.collectors, c)
+}
+
+// Counter registers a monotonically increasing metric partitioned by the given label names
+func (registry *Registry) Counter(name, help string, labelNames ...string) *Counter {
+	counter := &Counter{family: newFamily(name, help, "counter", labelNames)}
+	registry.register(counter)
+	return counter
+}
+
+// Gauge registers a metric that can go up and down
+func (registry *Registry) Gauge(name, help string, labelNames ...string) *Gauge {
+	gauge := &Gauge{family: newFamily(name, help, "gauge", labelNames)}
+	registry.register(gauge)
+	return gauge
+}
+
+// GaugeFunc registers a gauge whose value is computed on each scrape
+func (registry *Registry) GaugeFunc(name, help string, labels Labels, fn func() float64) {
+	registry.register(&gaugeFunc{name: name, help: help, labels: labels, fn: fn})
+}
+
+// Histogram registers a metric observing values into cumulative buckets.
+// Buckets must be sorted in increasing order, DefaultBuckets are used when nil.
+func (registry *Registry) Histogram(name, help string, buckets []float64, labelNames ...string) *Histogram {
+	if buckets == nil {
+		buckets = DefaultBuckets
+	}
+
+	histogram := &Histogram{family: newFamily(name, help, "histogram", labelNames), buckets: buckets}
+	registry.register(histogram)
+	return histogram
+}
+
+// DefaultBuckets fit handler latencies in seconds
+var DefaultBuckets = []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5}
+
+// Write writes every registered metric to w
+func (registry *Registry) Write(w io.Writer) error {
+	registry.mutex.Lock()
+	collectors := append([]collector(nil), registry.collectors...)
+	registry.mutex.Unlock()
+
+	writer := bufio.NewWriter(w)
+
+	for _, c := range collectors {
+		c.write(writer)
+	}
+
+	return writer.Flush()
+}
+
+// ServeHTTP makes the registry usable as the /metrics handler
+func (registry *Registry) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+
+	_ = registry.Write(w)
+}
+
+// family holds the series of a metric keyed by their label values
+type family struct {
+	name       string
+	help       string
+	kind       string
+	labelNames []string
+	mutex      sync.Mutex
+	series     map[string]*series
+}
+
+type series struct {
+	labelValues []string
+	value       float64
+	// buckets, sum and count are only used by histograms
+	buckets []uint64
+	sum     float64
+	count   uint64
+}
+
+func newFamily(name, help, kind string, labelNames []string) family {
+	return family{
+		name:       name,
+		help:       help,
+		kind:       kind,
+		labelNames: labelNames,
+		series:     map[string]*series{},
+	}
+}
+
+// load returns the series of the label values, the caller must hold the mutex
+func (f *family) load(labelValues []string) *series {
+	if len(labelValues) != len(f.labelNames) {
+		panic(fmt.Sprintf("metric %s expects %d label values, got %d", f.name, len(f.labelNames), len(labelValues)))
+	}
+
+	key := strings.Join(labelValues, "\xff")
+
+	s, exists := f.series[key]
+
+	if !exists {
+		s = &series{labelValues: append([]string(nil), labelValues...)}
+		f.series[key] = s
+	}
+
+	return s
+}
+
+// sorted returns the series ordered by label values, so the output is stable
+func (f *family) sorted() []*series {
+	keys := make([]string, 0, len(f.series))
+
+	for key := range f.series {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	output := make([]*series, 0, len(keys))
+
+	for _, key := range keys {
+		output = append(output, f.series[key])
+	}
+
+	return output
+}
+
+func (f *family) writeHeader(w *bufio.Writer) {
+	_, _ = fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", f.name, escapeHelp(f.help), f.name, f.kind)
+}
+
+func (f *family) write(w *bufio.Writer) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.writeHeader(w)
+
+	for _, s := range f.sorted() {
+		writeSample(w, f.name, f.labelNames, s.labelValues, "", "", s.value)
+	}
+}
+
+type Counter struct {
+	family
+}
+
+func (counter *Counter) Inc(labelValues ...string) {
+	counter.Add(1, labelValues...)
+}
+
+// Add increases the counter, negative values are ignored
+func (counter *Counter) Add(value float64, labelValues ...string) {
+	if value < 0 {
+		return
+	}
+
+	counter.mutex.Lock()
+	counter.load(labelValues).value += value
+	counter.mutex.Unlock()
+}
+
+type Gauge struct {
+	family
+}
+
+func (gauge *Gauge) Set(value float64, labelValues ...string) {
+	gauge.mutex.Lock()
+	gauge.load(labelValues).value = value
+	gauge.mutex.Unlock()
+}
+
+func (gauge *Gauge) Add(value float64, labelValues ...string) {
+	gauge.mutex.Lock()
+	gauge.load(labelValues).value += value
+	gauge.mutex.Unlock()
+}
+
+type gaugeFunc struct {
+	name   string
+	help   string
+	labels Labels
+	fn     func() float64
+}
+
+func (g *gaugeFunc) write(w *bufio.Writer) {
+	names := make([]string, 0, len(g.labels))
+
+	for name := range g.labels {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	values := make([]string, 0, len(names))
+
+	for _, name := range names {
+		values = append(values, g.labels[name])
+	}
+
+	_, _ = fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n", g.name, escapeHelp(g.help), g.name)
+	writeSample(w, g.name, names, values, "", "", g.fn())
+}
+
+type Histogram struct {
+	family
+	buckets []float64
+}
+
+func (histogram *Histogram) Observe(value float64, labelValues ...string) {
+	histogram.mutex.Lock()
+	defer histogram.mutex.Unlock()
+
+	s := histogram.load(labelValues)
+
+	if s.buckets == nil {
+		s.buckets = make([]uint64, len(histogram.buckets))
+	}
+
+	for i, upperBound := range histogram.buckets {
+		if value <= upperBound {
+			s.buckets[i]++
+		}
+	}
+
+	s.sum += value
+	s.count++
+}
+
+func (histogram *Histogram) write(w *bufio.Writer) {
+	histogram.mutex.Lock()
+	defer histogram.mutex.Unlock()
+
+	histogram.writeHeader(w)
+
+	for _, s := range histogram.sorted() {
+		for i, upperBound := range histogram.buckets {
+			writeSample(w, histogram.name+"_bucket", histogram.labelNames, s.labelValues, "le", formatFloat(upperBound), float64(s.buckets[i]))
+		}
+
+		writeSample(w, histogram.name+"_bucket", histogram.labelNames, s.labelValues, "le", "+Inf", float64(s.count))
+		writeSample(w, histogram.name+"_sum", histogram.labelNames, s.labelValues, "", "", s.sum)
+		writeSample(w, histogram.name+"_count", histogram.labelNames, s.labelValues, "", "", float64(s.count))
+	}
+}
+
+// writeSample writes a single line, extraName and extraValue are used for the "le" label of histograms
+func writeSample(w *bufio.Writer, name string, labelNames, labelValues []string, extraName, extraValue string, value float64) {
+	_, _ = w.WriteString(name)
+
+	if len(labelNames) > 0 || extraName != "" {
+		_ = w.WriteByte('{')
+
+		for i, labelName := range labelNames {
+			if i > 0 {
+				_ = w.WriteByte(',')
+			}
+
+			_, _ = fmt.Fprintf(w, "%s=\"%s\"", labelName, escapeLabelValue(labelValues[i]))
+		}
+
+		if extraName != "" {
+			if len(labelNames) > 0 {
+				_ = w.WriteByte(',')
+			}
+
+			_, _ = fmt.Fprintf(w, "%s=\"%s\"", extraName, extraValue)
+		}
+
+		_ = w.WriteByte('}')
+	}
+
+	_ = w.WriteByte(' ')
+	_, _ = w.WriteString(formatFloat(value))
+	_ = w.WriteByte('\n')
+}
+
+func formatFloat(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	case math.IsNaN(value):
+		return "NaN"
+	default:
+		return strconv.FormatFloat(value, 'g', -1, 64)
+	}
+}
+
+var labelValueReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+var helpReplacer = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+
+func escapeLabelValue(value string) string {
+	return labelValueReplacer.Replace(value)
+}
+
+func escapeHelp(help string) string {
+	return helpReplacer.Replace(help)
+}
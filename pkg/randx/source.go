@@ -0,0 +1,40 @@
+package randx
+
+import (
+	"math/rand"
+	"sync"
+)
+
+// lockedSource guards a rand.Source64, which is not safe for concurrent use
+type lockedSource struct {
+	mutex  sync.Mutex
+	source rand.Source64
+}
+
+// New returns a rand.Rand seeded with seed that can be shared between goroutines.
+// The sequence only depends on the seed and on the order of the calls, except
+// Read which keeps its own buffer and must not be called concurrently.
+func New(seed int64) *rand.Rand {
+	return rand.New(&lockedSource{source: rand.NewSource(seed).(rand.Source64)})
+}
+
+func (s *lockedSource) Int63() int64 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.source.Int63()
+}
+
+func (s *lockedSource) Uint64() uint64 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.source.Uint64()
+}
+
+func (s *lockedSource) Seed(seed int64) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.source.Seed(seed)
+}
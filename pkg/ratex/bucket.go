@@ -0,0 +1,52 @@
+package ratex
+
+import (
+	"sync"
+	"time"
+)
+
+// Bucket is a token bucket allowing rate events per second with bursts of up to burst events
+type Bucket struct {
+	mutex  sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// NewBucket creates a full bucket
+func NewBucket(rate float64, burst int) *Bucket {
+	if burst < 1 {
+		burst = 1
+	}
+
+	return &Bucket{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// Allow takes a token from the bucket and reports whether there was one
+func (bucket *Bucket) Allow() bool {
+	bucket.mutex.Lock()
+	defer bucket.mutex.Unlock()
+
+	now := time.Now()
+
+	bucket.tokens += now.Sub(bucket.last).Seconds() * bucket.rate
+	bucket.last = now
+
+	if bucket.tokens > bucket.burst {
+		bucket.tokens = bucket.burst
+	}
+
+	if bucket.tokens < 1 {
+		return false
+	}
+
+	bucket.tokens--
+
+	return true
+}
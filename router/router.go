@@ -0,0 +1,183 @@
+package router
+
+import (
+	"errors"
+
+	"github.com/AmirRezaM75/kenopsiarelay/codecs"
+	"github.com/AmirRezaM75/kenopsiarelay/entities"
+	"github.com/AmirRezaM75/kenopsiarelay/schemas"
+)
+
+var (
+	InvalidMessage = entities.NewClientError("invalid_message", "The message is not a valid envelope.")
+	UnknownType    = entities.NewClientError("unknown_type", "The message type is not supported.")
+	InvalidPayload = entities.NewClientError("invalid_payload", "The message payload is invalid.")
+)
+
+// Context carries the envelope metadata of the message being handled
+type Context[S entities.GameState] struct {
+	Hub       *entities.Hub[S]
+	Type      string
+	RequestId string
+	// Codec is the codec of the sending player
+	Codec codecs.Codec
+}
+
+// Reply sends a typed message to the player, referring to the request being handled
+func (ctx *Context[S]) Reply(player *entities.Player, messageType string, payload any) error {
+	codec := ctx.Hub.CodecOf(player)
+
+	body, err := codecs.Encode(codec, messageType, ctx.RequestId, payload)
+
+	if err != nil {
+		return err
+	}
+
+	ctx.Hub.Send(&schemas.DispatcherMessage{
+		Body:        body,
+		GameId:      player.GameId,
+		ReceiverIds: []string{player.Id},
+		FrameType:   codec.FrameType(),
+	})
+
+	return nil
+}
+
+// HandlerFunc handles a single message type whose payload is decoded into T
+type HandlerFunc[S entities.GameState, T any] func(ctx *Context[S], game *entities.Game[S], player *entities.Player, payload T) error
+
+type route[S entities.GameState] func(ctx *Context[S], game *entities.Game[S], player *entities.Player, payload []byte) error
+
+// Router decodes the envelope and calls the handler registered for its type.
+// Use OnMessageReceived as the hub's entities.MessageReceivedHandler.
+type Router[S entities.GameState] struct {
+	routes map[string]route[S]
+}
+
+func New[S entities.GameState]() *Router[S] {
+	return &Router[S]{routes: map[string]route[S]{}}
+}
+
+// Handle registers the handler of the message type, the payload is decoded automatically
+// with the codec of the sending player.
+// It is a function and not a method because Go methods can not have type parameters.
+// Handlers must be registered before the hub starts serving players.
+func Handle[S entities.GameState, T any](router *Router[S], messageType string, handler HandlerFunc[S, T]) {
+	router.routes[messageType] = func(ctx *Context[S], game *entities.Game[S], player *entities.Player, raw []byte) error {
+		var payload T
+
+		if len(raw) > 0 {
+			err := ctx.Codec.Unmarshal(raw, &payload)
+
+			if err != nil {
+				return InvalidPayload.WithRequestId(ctx.RequestId)
+			}
+		}
+
+		return handler(ctx, game, player, payload)
+	}
+}
+
+// OnMessageReceived implements entities.MessageReceivedHandler
+func (router *Router[S]) OnMessageReceived(hub *entities.Hub[S], game *entities.Game[S], player *entities.Player, message []byte) error {
+	codec := hub.CodecOf(player)
+
+	envelope, err := codec.DecodeEnvelope(message)
+
+	if err != nil || envelope.Type == "" {
+		return InvalidMessage
+	}
+
+	handler, exists := router.routes[envelope.Type]
+
+	if !exists {
+		return UnknownType.WithRequestId(envelope.RequestId)
+	}
+
+	ctx := &Context[S]{Hub: hub, Type: envelope.Type, RequestId: envelope.RequestId, Codec: codec}
+
+	err = handler(ctx, game, player, envelope.Payload)
+
+	// Handlers do not need to know about the request id to reject a message
+	var clientError *entities.ClientError
+
+	if errors.As(err, &clientError) && clientError.RequestId == "" {
+		return clientError.WithRequestId(envelope.RequestId)
+	}
+
+	return err
+}
+
+// Send encodes a typed message for each receiver with its own codec
+// and pushes it through Hub.Send. Receivers sharing a codec share
+// a single DispatcherMessage, so the payload is encoded once per codec.
+func Send[S entities.GameState, T any](hub *entities.Hub[S], gameId string, receiverIds []string, messageType string, payload T) error {
+	return send(hub, gameId, receiverIds, messageType, payload, false)
+}
+
+// SendVisible is Send for messages the spectators of the game are allowed to see,
+// e.g. public moves. Spectators get the message in their own codec as well.
+func SendVisible[S entities.GameState, T any](hub *entities.Hub[S], gameId string, receiverIds []string, messageType string, payload T) error {
+	return send(hub, gameId, receiverIds, messageType, payload, true)
+}
+
+func send[S entities.GameState, T any](hub *entities.Hub[S], gameId string, receiverIds []string, messageType string, payload T, spectatorVisible bool) error {
+	game := hub.FindGame(gameId)
+
+	if game == nil {
+		return entities.GameNotFound
+	}
+
+	// Codecs are grouped by name, a codec is not necessarily comparable
+	var (
+		order  []codecs.Codec
+		groups = map[string][]string{}
+	)
+
+	for _, receiverId := range receiverIds {
+		player, exists := game.Players.Load(receiverId)
+
+		if !exists {
+			continue
+		}
+
+		codec := hub.CodecOf(player)
+
+		if _, exists = groups[codec.Name()]; !exists {
+			order = append(order, codec)
+		}
+
+		groups[codec.Name()] = append(groups[codec.Name()], receiverId)
+	}
+
+	if spectatorVisible {
+		game.Spectators.Range(func(spectatorId string, spectator *entities.Player) bool {
+			codec := hub.CodecOf(spectator)
+
+			if _, exists := groups[codec.Name()]; !exists {
+				order = append(order, codec)
+				groups[codec.Name()] = nil
+			}
+			return true
+		})
+	}
+
+	for _, codec := range order {
+		body, err := codecs.Encode(codec, messageType, "", payload)
+
+		if err != nil {
+			return err
+		}
+
+		hub.Send(&schemas.DispatcherMessage{
+			Body:             body,
+			GameId:           gameId,
+			ReceiverIds:      groups[codec.Name()],
+			FrameType:        codec.FrameType(),
+			SpectatorVisible: spectatorVisible,
+			Codec:            codec.Name(),
+		})
+	}
+
+	return nil
+}
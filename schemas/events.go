@@ -9,38 +9,91 @@ type PublisherEvent struct {
 	Content string `json:"content"`
 }
 
-func GameCreatedEvent(gameId, lobbyId, gameSlug string) (string, error) {
+// GameCreatedEvent carries the seed of the game's random source so the game can be replayed
+func GameCreatedEvent(gameId, lobbyId, gameSlug, status string, seed int64) (string, error) {
 	type GameCreatedContent struct {
 		GameId   string `json:"gameId"`
 		LobbyId  string `json:"lobbyId"`
 		GameSlug string `json:"gameSlug"`
+		Status   string `json:"status"`
+		Seed     int64  `json:"seed"`
 	}
 
 	content := GameCreatedContent{
 		GameId:   gameId,
 		LobbyId:  lobbyId,
 		GameSlug: gameSlug,
+		Status:   status,
+		Seed:     seed,
 	}
 
 	return encode("GameCreated", content)
 }
 
-func GameEndedEvent(gameId, lobbyId, gameSlug string) (string, error) {
+func GameEndedEvent(gameId, lobbyId, gameSlug, status string) (string, error) {
 	type GameEndedContent struct {
 		GameId   string `json:"gameId"`
 		LobbyId  string `json:"lobbyId"`
 		GameSlug string `json:"gameSlug"`
+		Status   string `json:"status"`
 	}
 
 	content := GameEndedContent{
 		GameId:   gameId,
 		LobbyId:  lobbyId,
 		GameSlug: gameSlug,
+		Status:   status,
 	}
 
 	return encode("GameEnded", content)
 }
 
+func GameStatusChangedEvent(gameId, lobbyId, gameSlug, from, to string) (string, error) {
+	type GameStatusChangedContent struct {
+		GameId   string `json:"gameId"`
+		LobbyId  string `json:"lobbyId"`
+		GameSlug string `json:"gameSlug"`
+		From     string `json:"from"`
+		To       string `json:"to"`
+	}
+
+	content := GameStatusChangedContent{
+		GameId:   gameId,
+		LobbyId:  lobbyId,
+		GameSlug: gameSlug,
+		From:     from,
+		To:       to,
+	}
+
+	return encode("GameStatusChanged", content)
+}
+
+func BotTakeoverEvent(gameId, lobbyId, gameSlug, playerId string, seat int) (string, error) {
+	return encode("BotTakeover", botSeatContent(gameId, lobbyId, gameSlug, playerId, seat))
+}
+
+func BotHandbackEvent(gameId, lobbyId, gameSlug, playerId string, seat int) (string, error) {
+	return encode("BotHandback", botSeatContent(gameId, lobbyId, gameSlug, playerId, seat))
+}
+
+type BotSeatContent struct {
+	GameId   string `json:"gameId"`
+	LobbyId  string `json:"lobbyId"`
+	GameSlug string `json:"gameSlug"`
+	PlayerId string `json:"playerId"`
+	Seat     int    `json:"seat"`
+}
+
+func botSeatContent(gameId, lobbyId, gameSlug, playerId string, seat int) BotSeatContent {
+	return BotSeatContent{
+		GameId:   gameId,
+		LobbyId:  lobbyId,
+		GameSlug: gameSlug,
+		PlayerId: playerId,
+		Seat:     seat,
+	}
+}
+
 func encode(eventType string, content any) (string, error) {
 	message, err := json.Marshal(content)
 	if err != nil {
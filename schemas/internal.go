@@ -4,4 +4,14 @@ type DispatcherMessage struct {
 	Body        []byte
 	GameId      string
 	ReceiverIds []string
+	// CoalesceKey allows a newer message to replace a queued one with the same key
+	// when the receiver is slow and entities.SlowConsumerCoalesce policy is used.
+	CoalesceKey string
+	// FrameType is websocket.TextMessage or websocket.BinaryMessage, zero means binary.
+	// It should match the codec of the receivers, e.g. text frames for JSON clients.
+	FrameType int
+	// SpectatorVisible delivers the message to the spectators of the game as well.
+	// Only spectators using the codec named by Codec receive it, empty Codec matches every spectator.
+	SpectatorVisible bool
+	Codec            string
 }
@@ -7,3 +7,13 @@ type CreateGameResponse struct {
 type ErrorResponse struct {
 	Message string `json:"message"`
 }
+
+// ResumeMessageType is the envelope type of ResumeTokenMessage
+const ResumeMessageType = "resume"
+
+// ResumeTokenMessage is sent to a player after each join, the client presents
+// the token as the resumeToken query parameter to reconnect without a ticket
+type ResumeTokenMessage struct {
+	Token     string `json:"token"`
+	ExpiresAt int64  `json:"expiresAt"`
+}
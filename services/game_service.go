@@ -2,10 +2,10 @@ package services
 
 import (
 	"errors"
-	"math/rand"
 	"strconv"
 	"time"
 
+	"github.com/AmirRezaM75/kenopsiarelay/codecs"
 	"github.com/AmirRezaM75/kenopsiarelay/entities"
 	"github.com/AmirRezaM75/kenopsiarelay/pkg/logx"
 	"github.com/AmirRezaM75/kenopsiarelay/schemas"
@@ -21,6 +21,9 @@ type GameService[S entities.GameState] struct {
 	userRepository   kenopsiauser.UserRepository
 	lobbyRepository  kenopsialobby.LobbyRepository
 	publisherService PublisherService
+	// resumeTokenService lets a client reconnect without a new ticket
+	resumeTokenService ResumeTokenService
+	metrics            gameServiceMetrics
 }
 
 func NewGameService[S entities.GameState](
@@ -28,23 +31,36 @@ func NewGameService[S entities.GameState](
 	userRepository kenopsiauser.UserRepository,
 	lobbyRepository kenopsialobby.LobbyRepository,
 	publisherService PublisherService,
+	resumeTokenService ResumeTokenService,
 ) GameService[S] {
 	return GameService[S]{
-		hub:              hub,
-		userRepository:   userRepository,
-		lobbyRepository:  lobbyRepository,
-		publisherService: publisherService,
+		hub:                hub,
+		userRepository:     userRepository,
+		lobbyRepository:    lobbyRepository,
+		publisherService:   publisherService,
+		resumeTokenService: resumeTokenService,
+		metrics:            newGameServiceMetrics(hub.Metrics.Registry),
 	}
 }
 
 var (
 	InvalidTicket  = errors.New("ticket is not valid")
-	GameNotFound   = errors.New("game not found")
-	PlayerNotFound = errors.New("player not found")
+	GameNotFound   = entities.GameNotFound
+	PlayerNotFound = entities.PlayerNotFound
+	PlayerBanned   = entities.PlayerBanned
+	ShuttingDown   = entities.HubShuttingDown
 	LobbyNotFound  = errors.New("lobby not found")
 )
 
 func (gameService GameService[S]) Join(gameId, ticketId string, connection *websocket.Conn) (func(), error) {
+	reader, err := gameService.join(gameId, ticketId, connection)
+
+	gameService.metrics.joins.Inc(gameService.hub.GameSlug, outcome(err))
+
+	return reader, err
+}
+
+func (gameService GameService[S]) join(gameId, ticketId string, connection *websocket.Conn) (func(), error) {
 	userId, err := gameService.userRepository.AcquireUserId(ticketId)
 
 	if err != nil {
@@ -55,25 +71,156 @@ func (gameService GameService[S]) Join(gameId, ticketId string, connection *webs
 		return nil, InvalidTicket
 	}
 
+	if gameService.hub.Draining() {
+		return nil, ShuttingDown
+	}
+
 	game := gameService.hub.FindGame(gameId)
 
 	if game == nil {
 		return nil, GameNotFound
 	}
 
+	if game.IsBanned(userId) {
+		return nil, PlayerBanned
+	}
+
 	player, exists := game.Players.Load(userId)
 
 	if !exists {
 		return nil, PlayerNotFound
 	}
 
+	return gameService.connect(game, player, connection)
+}
+
+// Resume is Join for a client holding a resume token instead of a ticket,
+// so reconnecting does not need a round trip to the user service
+func (gameService GameService[S]) Resume(gameId, resumeToken string, connection *websocket.Conn) (func(), error) {
+	reader, err := gameService.resume(gameId, resumeToken, connection)
+
+	gameService.metrics.resumes.Inc(gameService.hub.GameSlug, outcome(err))
+
+	return reader, err
+}
+
+func (gameService GameService[S]) resume(gameId, resumeToken string, connection *websocket.Conn) (func(), error) {
+	claims, err := gameService.resumeTokenService.Verify(resumeToken)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if claims.GameId != gameId {
+		return nil, InvalidResumeToken
+	}
+
+	if gameService.hub.Draining() {
+		return nil, ShuttingDown
+	}
+
+	// A removed game can not be found, which invalidates its tokens
+	game := gameService.hub.FindGame(gameId)
+
+	if game == nil {
+		return nil, GameNotFound
+	}
+
+	player, exists := game.Players.Load(claims.PlayerId)
+
+	if !exists {
+		return nil, PlayerNotFound
+	}
+
+	// The player is kicked after the token is issued
+	if player.Epoch() != claims.Epoch {
+		return nil, InvalidResumeToken
+	}
+
+	return gameService.connect(game, player, connection)
+}
+
+// Spectate joins the game as a spectator, the user does not need to be in the lobby
+func (gameService GameService[S]) Spectate(gameId, ticketId string, connection *websocket.Conn) (func(), error) {
+	reader, err := gameService.spectate(gameId, ticketId, connection)
+
+	gameService.metrics.spectates.Inc(gameService.hub.GameSlug, outcome(err))
+
+	return reader, err
+}
+
+func (gameService GameService[S]) spectate(gameId, ticketId string, connection *websocket.Conn) (func(), error) {
+	userId, err := gameService.userRepository.AcquireUserId(ticketId)
+
+	if err != nil {
+		logx.Logger.Error(
+			err.Error(),
+			zap.String("desc", "could not acquire user by ticket"),
+		)
+		return nil, InvalidTicket
+	}
+
+	if gameService.hub.Draining() {
+		return nil, ShuttingDown
+	}
+
+	game := gameService.hub.FindGame(gameId)
+
+	if game == nil {
+		return nil, GameNotFound
+	}
+
+	spectatorConnection := entities.NewConnection(connection, gameService.hub.ConnectionOptions(connection))
+
+	spectator, err := gameService.hub.AddSpectator(game, userId, spectatorConnection)
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = gameService.hub.HandleSpectatorJoined(game, spectator)
+
+	if err != nil {
+		logx.Logger.Error(
+			err.Error(),
+			zap.String("desc", "could not execute handler when spectator is joined"),
+			zap.String("gameId", game.Id),
+			zap.String("playerId", spectator.Id),
+		)
+		gameService.hub.Detach(spectator, spectatorConnection)
+		return nil, err
+	}
+
+	reader, err := gameService.hub.Serve(spectator, spectatorConnection)
+
+	if err != nil {
+		gameService.hub.Detach(spectator, spectatorConnection)
+		return nil, err
+	}
+
+	return reader, nil
+}
+
+// connect attaches the connection to the player, fires the join hooks
+// and sends a fresh resume token once the connection is served
+func (gameService GameService[S]) connect(game *entities.Game[S], player *entities.Player, connection *websocket.Conn) (func(), error) {
 	// CRITICAL FIX: Use atomic reconnection to prevent race conditions
 	// Previously, Kick() would lock/unlock mutex but then we'd modify player state without protection
 	// This could cause Hub.Run() to read inconsistent state or send to wrong channel, causing panics
 	// The new Reconnect() method handles all state changes atomically under mutex protection
-	player.Reconnect(connection)
+	playerConnection := entities.NewConnection(connection, gameService.hub.ConnectionOptions(connection))
+
+	reconnected, err := player.Reconnect(playerConnection)
+
+	if err != nil {
+		return nil, err
+	}
 
-	err = gameService.hub.OnPlayerJoined(gameService.hub, game, player)
+	if reconnected {
+		err = gameService.hub.HandlePlayerReconnected(game, player)
+	} else {
+		err = gameService.hub.HandlePlayerJoined(game, player)
+	}
 
 	if err != nil {
 		logx.Logger.Error(
@@ -82,19 +229,80 @@ func (gameService GameService[S]) Join(gameId, ticketId string, connection *webs
 			zap.String("gameId", game.Id),
 			zap.String("playerId", player.Id),
 		)
+		gameService.hub.Detach(player, playerConnection)
 		return nil, err
 	}
 
-	go player.Write()
+	reader, err := gameService.hub.Serve(player, playerConnection)
+
+	if err != nil {
+		gameService.hub.Detach(player, playerConnection)
+		return nil, err
+	}
+
+	gameService.sendResumeToken(game, player)
+
+	return reader, nil
+}
+
+// sendResumeToken sends a "resume" message carrying the token to the player,
+// nothing is sent when resume tokens are disabled
+func (gameService GameService[S]) sendResumeToken(game *entities.Game[S], player *entities.Player) {
+	if !gameService.resumeTokenService.Enabled() {
+		return
+	}
+
+	token, claims, err := gameService.resumeTokenService.Issue(game.Id, player.Id, player.Epoch())
+
+	if err != nil {
+		logx.Logger.Error(
+			err.Error(),
+			zap.String("desc", "could not issue resume token"),
+			zap.String("gameId", game.Id),
+			zap.String("playerId", player.Id),
+		)
+		return
+	}
+
+	codec := gameService.hub.CodecOf(player)
+
+	body, err := codecs.Encode(codec, schemas.ResumeMessageType, "", schemas.ResumeTokenMessage{
+		Token:     token,
+		ExpiresAt: claims.ExpiresAt,
+	})
 
-	return func() {
-		entities.Read(player, gameService.hub)
-	}, nil
+	if err != nil {
+		logx.Logger.Error(
+			err.Error(),
+			zap.String("desc", "could not encode resume token"),
+			zap.String("gameId", game.Id),
+			zap.String("playerId", player.Id),
+		)
+		return
+	}
+
+	gameService.hub.Send(&schemas.DispatcherMessage{
+		Body:        body,
+		GameId:      game.Id,
+		ReceiverIds: []string{player.Id},
+		FrameType:   codec.FrameType(),
+	})
 }
 
 func (gameService GameService[S]) Create(
 	user kenopsiauser.User,
 	payload schemas.CreateGameRequest,
+) (*schemas.CreateGameResponse, error) {
+	response, err := gameService.create(user, payload)
+
+	gameService.metrics.gamesCreated.Inc(gameService.hub.GameSlug, outcome(err))
+
+	return response, err
+}
+
+func (gameService GameService[S]) create(
+	user kenopsiauser.User,
+	payload schemas.CreateGameRequest,
 ) (*schemas.CreateGameResponse, error) {
 	lobby, err := gameService.lobbyRepository.FindById(payload.LobbyId)
 
@@ -113,53 +321,59 @@ func (gameService GameService[S]) Create(
 
 	game := &entities.Game[S]{
 		Id:        bson.NewObjectID().Hex(),
-		Status:    "pending",
 		CreatorId: user.Id,
 		CreatedAt: time.Now().Unix(),
 		LobbyId:   lobby.Id,
 		State:     gameService.hub.GameStateFactory(),
 	}
 
-	humansCount := len(lobby.Players)
-	botsCount := len(lobby.Bots)
-
-	indexes := rand.Perm(humansCount + botsCount)
-
-	index := 0
+	players := make([]*entities.Player, 0, len(lobby.Players)+len(lobby.Bots))
 
 	for _, player := range lobby.Players {
-		game.Players.Store(player.Id, &entities.Player{
+		players = append(players, &entities.Player{
 			Id:          player.Id,
 			Username:    player.Username,
 			GameId:      game.Id,
 			AvatarId:    player.AvatarId,
-			Index:       indexes[index] + 1,
 			IsConnected: false,
-			IsClosed:    true,
 			IsBot:       false,
 		})
-		index++
 	}
 
 	for _, bot := range lobby.Bots {
 		var botId = strconv.Itoa(int(bot.Id))
 
-		game.Players.Store(botId, &entities.Player{
+		players = append(players, &entities.Player{
 			Id:          botId,
 			Username:    bot.Username,
 			GameId:      game.Id,
 			AvatarId:    bot.AvatarId,
-			Index:       indexes[index] + 1,
 			IsConnected: true,
-			IsClosed:    true,
 			IsBot:       true,
 		})
-		index++
 	}
 
-	gameService.hub.Games.Store(game.Id, game)
+	// Seating uses the game's random source, so it is replayed with the seed as well
+	gameService.hub.SeedGame(game)
+
+	err = gameService.hub.SeatPlayers(players, game.Rand())
+
+	if err != nil {
+		logx.Logger.Error(
+			err.Error(),
+			zap.String("lobbyId", payload.LobbyId),
+			zap.String("desc", "could not seat players"),
+		)
+		return nil, err
+	}
+
+	for _, player := range players {
+		game.Players.Store(player.Id, player)
+	}
+
+	gameService.hub.AddGame(game)
 
-	message, err := schemas.GameCreatedEvent(game.Id, lobby.Id, gameService.hub.GameSlug)
+	message, err := schemas.GameCreatedEvent(game.Id, lobby.Id, gameService.hub.GameSlug, string(game.Status()), game.Seed)
 
 	if err != nil {
 		logx.Logger.Error(
@@ -177,7 +391,7 @@ func (gameService GameService[S]) Create(
 		return nil, err
 	}
 
-	err = gameService.hub.OnGameCreated(gameService.hub, game)
+	err = gameService.hub.HandleGameCreated(game)
 
 	if err != nil {
 		logx.Logger.Error(
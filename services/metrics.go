@@ -0,0 +1,74 @@
+package services
+
+import (
+	"errors"
+
+	"github.com/AmirRezaM75/kenopsiarelay/entities"
+	"github.com/AmirRezaM75/kenopsiarelay/pkg/metricsx"
+)
+
+type gameServiceMetrics struct {
+	gamesCreated *metricsx.Counter
+	joins        *metricsx.Counter
+	resumes      *metricsx.Counter
+	spectates    *metricsx.Counter
+}
+
+func newGameServiceMetrics(registry *metricsx.Registry) gameServiceMetrics {
+	return gameServiceMetrics{
+		gamesCreated: registry.Counter(
+			"kenopsia_relay_games_created_total",
+			"Create game requests by outcome.",
+			"game", "outcome",
+		),
+		joins: registry.Counter(
+			"kenopsia_relay_joins_total",
+			"Join requests by outcome.",
+			"game", "outcome",
+		),
+		resumes: registry.Counter(
+			"kenopsia_relay_resumes_total",
+			"Join requests with a resume token by outcome.",
+			"game", "outcome",
+		),
+		spectates: registry.Counter(
+			"kenopsia_relay_spectates_total",
+			"Spectate requests by outcome.",
+			"game", "outcome",
+		),
+	}
+}
+
+// outcome converts known errors of the service to a metric label
+func outcome(err error) string {
+	switch {
+	case err == nil:
+		return entities.OutcomeOk
+	case errors.Is(err, InvalidTicket):
+		return "invalid_ticket"
+	case errors.Is(err, GameNotFound):
+		return "game_not_found"
+	case errors.Is(err, PlayerNotFound):
+		return "player_not_found"
+	case errors.Is(err, PlayerBanned):
+		return "player_banned"
+	case errors.Is(err, LobbyNotFound):
+		return "lobby_not_found"
+	case errors.Is(err, InvalidResumeToken), errors.Is(err, ResumeDisabled):
+		return "invalid_resume_token"
+	case errors.Is(err, ResumeTokenExpired):
+		return "resume_token_expired"
+	case errors.Is(err, entities.DuplicateConnection), errors.Is(err, entities.CodecMismatch):
+		return "duplicate_connection"
+	case errors.Is(err, entities.SpectatingDisabled):
+		return "spectating_disabled"
+	case errors.Is(err, entities.SpectatorsFull):
+		return "spectators_full"
+	case errors.Is(err, entities.SpectatorIsPlayer):
+		return "spectator_is_player"
+	case errors.Is(err, ShuttingDown):
+		return "shutting_down"
+	default:
+		return entities.OutcomeError
+	}
+}
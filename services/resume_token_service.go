@@ -0,0 +1,120 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+const defaultResumeTokenTTL = 2 * time.Minute
+
+var (
+	InvalidResumeToken = errors.New("resume token is not valid")
+	ResumeTokenExpired = errors.New("resume token is expired")
+	ResumeDisabled     = errors.New("resume tokens are disabled")
+)
+
+// ResumeClaims are signed into a resume token, so a reconnecting client can not
+// pick another game or player. Epoch must match Player.Epoch, which is bumped
+// whenever the player is kicked, so a kicked client has to acquire a new ticket.
+type ResumeClaims struct {
+	GameId    string `json:"gameId"`
+	PlayerId  string `json:"playerId"`
+	Epoch     uint64 `json:"epoch"`
+	ExpiresAt int64  `json:"expiresAt"`
+}
+
+// ResumeTokenService issues and verifies short-lived HMAC-SHA256 tokens.
+// A token is base64url(claims) + "." + base64url(signature), it is not encrypted,
+// so claims must never carry anything secret.
+type ResumeTokenService struct {
+	secret []byte
+	ttl    time.Duration
+}
+
+// NewResumeTokenService creates the service, an empty secret disables resume tokens
+func NewResumeTokenService(secret string, ttl time.Duration) ResumeTokenService {
+	if ttl <= 0 {
+		ttl = defaultResumeTokenTTL
+	}
+
+	return ResumeTokenService{secret: []byte(secret), ttl: ttl}
+}
+
+// Enabled reports whether a secret is configured
+func (resumeTokenService ResumeTokenService) Enabled() bool {
+	return len(resumeTokenService.secret) > 0
+}
+
+func (resumeTokenService ResumeTokenService) Issue(gameId, playerId string, epoch uint64) (string, ResumeClaims, error) {
+	if !resumeTokenService.Enabled() {
+		return "", ResumeClaims{}, ResumeDisabled
+	}
+
+	claims := ResumeClaims{
+		GameId:    gameId,
+		PlayerId:  playerId,
+		Epoch:     epoch,
+		ExpiresAt: time.Now().Add(resumeTokenService.ttl).Unix(),
+	}
+
+	payload, err := json.Marshal(claims)
+
+	if err != nil {
+		return "", ResumeClaims{}, err
+	}
+
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+
+	return encoded + "." + resumeTokenService.sign(encoded), claims, nil
+}
+
+// Verify checks the signature and the expiry, the claims must still be matched
+// against the game and the player by the caller
+func (resumeTokenService ResumeTokenService) Verify(token string) (ResumeClaims, error) {
+	if !resumeTokenService.Enabled() {
+		return ResumeClaims{}, ResumeDisabled
+	}
+
+	encoded, signature, found := strings.Cut(token, ".")
+
+	if !found {
+		return ResumeClaims{}, InvalidResumeToken
+	}
+
+	// hmac.Equal compares in constant time, so the signature can not be guessed byte by byte
+	if !hmac.Equal([]byte(signature), []byte(resumeTokenService.sign(encoded))) {
+		return ResumeClaims{}, InvalidResumeToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+
+	if err != nil {
+		return ResumeClaims{}, InvalidResumeToken
+	}
+
+	var claims ResumeClaims
+
+	err = json.Unmarshal(payload, &claims)
+
+	if err != nil {
+		return ResumeClaims{}, InvalidResumeToken
+	}
+
+	if time.Now().Unix() >= claims.ExpiresAt {
+		return ResumeClaims{}, ResumeTokenExpired
+	}
+
+	return claims, nil
+}
+
+func (resumeTokenService ResumeTokenService) sign(encoded string) string {
+	mac := hmac.New(sha256.New, resumeTokenService.secret)
+	mac.Write([]byte(encoded))
+
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
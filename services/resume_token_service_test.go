@@ -0,0 +1,164 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/AmirRezaM75/kenopsiarelay/entities"
+	"github.com/AmirRezaM75/kenopsiarelay/pkg/logx"
+	"github.com/amirrezam75/kenopsialobby"
+	"github.com/amirrezam75/kenopsiauser"
+)
+
+// forge signs the claims with the secret of the service, as Issue would
+func forge(resumeTokenService ResumeTokenService, claims ResumeClaims) string {
+	payload, _ := json.Marshal(claims)
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+
+	return encoded + "." + resumeTokenService.sign(encoded)
+}
+
+func TestResumeTokenRoundTrip(t *testing.T) {
+	resumeTokenService := NewResumeTokenService("secret", time.Minute)
+
+	token, issued, err := resumeTokenService.Issue("game", "player", 3)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims, err := resumeTokenService.Verify(token)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if claims != issued || claims.GameId != "game" || claims.PlayerId != "player" || claims.Epoch != 3 {
+		t.Fatalf("got %+v, want %+v", claims, issued)
+	}
+}
+
+func TestResumeTokenVerify(t *testing.T) {
+	resumeTokenService := NewResumeTokenService("secret", time.Minute)
+
+	token, _, err := resumeTokenService.Issue("game", "player", 0)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	other, _, err := resumeTokenService.Issue("game", "intruder", 0)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	encoded, signature, _ := strings.Cut(token, ".")
+	otherEncoded, _, _ := strings.Cut(other, ".")
+
+	tests := []struct {
+		name    string
+		service ResumeTokenService
+		token   string
+		err     error
+	}{
+		{"valid", resumeTokenService, token, nil},
+		{"without separator", resumeTokenService, encoded, InvalidResumeToken},
+		{"tampered payload", resumeTokenService, otherEncoded + "." + signature, InvalidResumeToken},
+		{"tampered signature", resumeTokenService, encoded + "." + strings.Repeat("A", len(signature)), InvalidResumeToken},
+		{"empty signature", resumeTokenService, encoded + ".", InvalidResumeToken},
+		{"other secret", NewResumeTokenService("other", time.Minute), token, InvalidResumeToken},
+		{"signed garbage", resumeTokenService, "!!!." + resumeTokenService.sign("!!!"), InvalidResumeToken},
+		{"expired", resumeTokenService, forge(resumeTokenService, ResumeClaims{
+			GameId:    "game",
+			PlayerId:  "player",
+			ExpiresAt: time.Now().Add(-time.Second).Unix(),
+		}), ResumeTokenExpired},
+		{"disabled", NewResumeTokenService("", time.Minute), token, ResumeDisabled},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := test.service.Verify(test.token)
+
+			if !errors.Is(err, test.err) {
+				t.Fatalf("got %v, want %v", err, test.err)
+			}
+		})
+	}
+}
+
+func TestResumeTokenIssueWhenDisabled(t *testing.T) {
+	resumeTokenService := NewResumeTokenService("", time.Minute)
+
+	if resumeTokenService.Enabled() {
+		t.Fatal("service without secret is enabled")
+	}
+
+	_, _, err := resumeTokenService.Issue("game", "player", 0)
+
+	if !errors.Is(err, ResumeDisabled) {
+		t.Fatalf("got %v, want %v", err, ResumeDisabled)
+	}
+}
+
+func TestResumeRejectsMismatchedClaims(t *testing.T) {
+	logx.NewLogger()
+
+	hub := entities.NewHub(&entities.HubConfig[int]{Context: context.Background()})
+	resumeTokenService := NewResumeTokenService("secret", time.Minute)
+	gameService := NewGameService(hub, kenopsiauser.UserRepository{}, kenopsialobby.LobbyRepository{}, PublisherService{}, resumeTokenService)
+
+	game := &entities.Game[int]{Id: "game"}
+	kicked := &entities.Player{Id: "kicked", GameId: game.Id}
+	game.Players.Store(kicked.Id, kicked)
+
+	hub.AddGame(game)
+	defer hub.RemoveGame(game.Id)
+
+	issue := func(gameId, playerId string, epoch uint64) string {
+		token, _, err := resumeTokenService.Issue(gameId, playerId, epoch)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return token
+	}
+
+	staleToken := issue(game.Id, kicked.Id, kicked.Epoch())
+	kicked.Kick()
+
+	tests := []struct {
+		name   string
+		gameId string
+		token  string
+		err    error
+	}{
+		{"other game", game.Id, issue("other", kicked.Id, kicked.Epoch()), InvalidResumeToken},
+		{"removed game", "other", issue("other", kicked.Id, 0), GameNotFound},
+		{"unknown player", game.Id, issue(game.Id, "unknown", 0), PlayerNotFound},
+		{"kicked after issue", game.Id, staleToken, InvalidResumeToken},
+		{"expired", game.Id, forge(resumeTokenService, ResumeClaims{
+			GameId:    game.Id,
+			PlayerId:  kicked.Id,
+			Epoch:     kicked.Epoch(),
+			ExpiresAt: time.Now().Add(-time.Second).Unix(),
+		}), ResumeTokenExpired},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := gameService.resume(test.gameId, test.token, nil)
+
+			if !errors.Is(err, test.err) {
+				t.Fatalf("got %v, want %v", err, test.err)
+			}
+		})
+	}
+}